@@ -1,6 +1,9 @@
 package app
 
 import (
+	"github.com/carreter/tasktree-go/pkg/query"
+	"github.com/carreter/tasktree-go/pkg/storage"
+	"github.com/carreter/tasktree-go/pkg/task"
 	"github.com/carreter/tasktree-go/pkg/tasktree"
 	"sync"
 )
@@ -9,10 +12,35 @@ import (
 type Context struct {
 	mu       sync.Mutex
 	taskTree *tasktree.TaskTree
+
+	store         *storage.Store
+	savedTree     *tasktree.TaskTree
+	savedRevision uint64
+
+	filterQuery string
+	filter      query.Expr
+
+	criticalPathGoal task.Id
+
+	report *tasktree.Report
+
+	pomodoroSettings PomodoroSettings
+	pomodoro         *Pomodoro
+
+	interactive bool
 }
 
-func NewContext(taskTree *tasktree.TaskTree) *Context {
-	return &Context{taskTree: taskTree}
+// NewContext creates a new Context. If store is non-nil, the task tree is
+// persisted to it whenever Sync is called.
+func NewContext(taskTree *tasktree.TaskTree, store *storage.Store) *Context {
+	return &Context{
+		taskTree:      taskTree,
+		store:         store,
+		savedTree:     taskTree,
+		savedRevision: taskTree.Revision(),
+
+		pomodoroSettings: DefaultPomodoroSettings,
+	}
 }
 
 func (ctx *Context) TaskTree() *tasktree.TaskTree {
@@ -22,3 +50,91 @@ func (ctx *Context) TaskTree() *tasktree.TaskTree {
 func (ctx *Context) SetTaskTree(taskTree *tasktree.TaskTree) {
 	ctx.taskTree = taskTree
 }
+
+// Filter returns the query the tree view is filtered by, or nil if it isn't filtered.
+func (ctx *Context) Filter() (string, query.Expr) {
+	return ctx.filterQuery, ctx.filter
+}
+
+// SetFilter sets the query the tree view is filtered by. A nil filter clears it.
+func (ctx *Context) SetFilter(rawQuery string, filter query.Expr) {
+	ctx.filterQuery = rawQuery
+	ctx.filter = filter
+}
+
+// CriticalPathGoal returns the task whose critical path is highlighted in the
+// tree view, or an empty Id if none is.
+func (ctx *Context) CriticalPathGoal() task.Id {
+	return ctx.criticalPathGoal
+}
+
+// SetCriticalPathGoal sets the task whose critical path is highlighted in the
+// tree view. An empty Id clears it.
+func (ctx *Context) SetCriticalPathGoal(id task.Id) {
+	ctx.criticalPathGoal = id
+}
+
+// Report returns the time report shown in place of the tree view, or nil if
+// none is.
+func (ctx *Context) Report() *tasktree.Report {
+	return ctx.report
+}
+
+// SetReport sets the time report shown in place of the tree view. A nil report
+// closes it.
+func (ctx *Context) SetReport(report *tasktree.Report) {
+	ctx.report = report
+}
+
+// PomodoroSettings returns the interval lengths new pomodoros use.
+func (ctx *Context) PomodoroSettings() PomodoroSettings {
+	return ctx.pomodoroSettings
+}
+
+// SetPomodoroSettings sets the interval lengths new pomodoros use.
+func (ctx *Context) SetPomodoroSettings(settings PomodoroSettings) {
+	ctx.pomodoroSettings = settings
+}
+
+// Pomodoro returns the running pomodoro, or nil if there is none.
+func (ctx *Context) Pomodoro() *Pomodoro {
+	return ctx.pomodoro
+}
+
+// SetPomodoro sets the running pomodoro. A nil pomodoro stops it.
+func (ctx *Context) SetPomodoro(pomodoro *Pomodoro) {
+	ctx.pomodoro = pomodoro
+}
+
+// Interactive returns whether commands are run from the interactive
+// interface, which owns the terminal, rather than from the command line.
+func (ctx *Context) Interactive() bool {
+	return ctx.interactive
+}
+
+// SetInteractive sets whether commands are run from the interactive interface.
+func (ctx *Context) SetInteractive(interactive bool) {
+	ctx.interactive = interactive
+}
+
+// Sync saves the task tree to the store if it has changed since it was last saved.
+func (ctx *Context) Sync() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if ctx.store == nil {
+		return nil
+	}
+
+	revision := ctx.taskTree.Revision()
+	if ctx.taskTree == ctx.savedTree && revision == ctx.savedRevision {
+		return nil
+	}
+
+	if err := ctx.store.Save(ctx.taskTree); err != nil {
+		return err
+	}
+	ctx.savedTree = ctx.taskTree
+	ctx.savedRevision = revision
+	return nil
+}
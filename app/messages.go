@@ -0,0 +1,33 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"time"
+)
+
+// A StatusMsg reports the outcome of an action taken outside of the command
+// bar (e.g. through a keybinding) so that it can be displayed there.
+// At most one of Output and Err is non-empty.
+type StatusMsg struct {
+	Output string
+	Err    string
+}
+
+// A TickMsg is sent every second to refresh live displays such as the elapsed
+// time of the running timer.
+type TickMsg time.Time
+
+// A BellMsg rings the terminal bell. The model writes the bell character in
+// its view until the next TickMsg, so that it goes out with the rest of the
+// program's output rather than in between.
+type BellMsg struct{}
+
+// Bell returns a command sending a BellMsg.
+func Bell() tea.Cmd {
+	return func() tea.Msg { return BellMsg{} }
+}
+
+// Tick returns a command sending a TickMsg after a second.
+func Tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return TickMsg(t) })
+}
@@ -12,10 +12,10 @@ type AddCommand struct {
 
 func (c AddCommand) Run(ctx *app.Context, args ...string) (string, string) {
 	if len(args) < 2 {
-		return "", fmt.Sprintf("inccorect number of arguments, usage: %v", c.Usage())
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
 	}
 
-	newTask := task.Task{Id: task.Id(args[1]), Name: args[1]}
+	newTask := task.Task{Id: task.NewId(), Name: args[1]}
 	if len(args) > 2 {
 		newTask.Description = strings.Trim(strings.Join(args[2:], " "), "\"")
 	}
@@ -24,8 +24,9 @@ func (c AddCommand) Run(ctx *app.Context, args ...string) (string, string) {
 	if err != nil {
 		return "", fmt.Sprintf("failed to add task: %v", err)
 	}
+	newTask, _ = ctx.TaskTree().GetTask(newTask.Id)
 
-	return fmt.Sprintf("added task with ID %v", newTask.Id), ""
+	return fmt.Sprintf("added task %v", newTask.Alias), ""
 }
 
 func (c AddCommand) Usage() string {
@@ -12,29 +12,30 @@ type AddSubtaskCommand struct {
 
 func (c AddSubtaskCommand) Run(ctx *app.Context, args ...string) (string, string) {
 	if len(args) < 3 {
-		return "", fmt.Sprintf("inccorect number of arguments, usage: %v", c.Usage())
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
 	}
 
-	newTask := task.Task{Id: task.Id(args[2]), Name: args[2]}
+	parent, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to add subtask: %v", err)
+	}
+
+	newTask := task.Task{Id: task.NewId(), Name: args[2]}
 	if len(args) > 3 {
 		newTask.Description = strings.Trim(strings.Join(args[3:], " "), "\"")
 	}
 
-	err := ctx.TaskTree().AddTask(newTask)
-	if err != nil {
-		return "", fmt.Sprintf("failed to add task: %v", err)
-	}
-	parentTaskId := task.Id(args[1])
-	err = ctx.TaskTree().MarkSubtask(parentTaskId, newTask.Id)
+	err = ctx.TaskTree().AddSubtask(parent.Id, newTask)
 	if err != nil {
 		return "", fmt.Sprintf("failed to add subtask: %v", err)
 	}
+	newTask, _ = ctx.TaskTree().GetTask(newTask.Id)
 
-	return fmt.Sprintf("added task %v as subtask of task %v", newTask.Id, parentTaskId), ""
+	return fmt.Sprintf("added task %v as subtask of task %v", newTask.Alias, parent.Alias), ""
 }
 
 func (c AddSubtaskCommand) Usage() string {
-	return "add-subtask <task name> [<task description>]"
+	return "add-subtask <parent task> <task name> [<task description>]"
 }
 
 func (c AddSubtaskCommand) Name() string {
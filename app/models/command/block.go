@@ -0,0 +1,127 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+)
+
+type BlockCommand struct {
+}
+
+func (c BlockCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	blocker, blocked, err := resolveTaskPair(ctx, args[1], args[2])
+	if err != nil {
+		return "", fmt.Sprintf("failed to mark blocker: %v", err)
+	}
+
+	err = ctx.TaskTree().MarkBlocker(blocker.Id, blocked.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to mark blocker: %v", err)
+	}
+
+	return fmt.Sprintf("task %v now blocks task %v", blocker.Alias, blocked.Alias), ""
+}
+
+func (c BlockCommand) Usage() string {
+	return "block <blocker task> <blocked task>"
+}
+
+func (c BlockCommand) Name() string {
+	return "block"
+}
+
+type UnblockCommand struct {
+}
+
+func (c UnblockCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	blocker, blocked, err := resolveTaskPair(ctx, args[1], args[2])
+	if err != nil {
+		return "", fmt.Sprintf("failed to unmark blocker: %v", err)
+	}
+
+	err = ctx.TaskTree().UnmarkBlocker(blocker.Id, blocked.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to unmark blocker: %v", err)
+	}
+
+	return fmt.Sprintf("task %v no longer blocks task %v", blocker.Alias, blocked.Alias), ""
+}
+
+func (c UnblockCommand) Usage() string {
+	return "unblock <blocker task> <blocked task>"
+}
+
+func (c UnblockCommand) Name() string {
+	return "unblock"
+}
+
+type BlockersCommand struct {
+}
+
+func (c BlockersCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to get blockers: %v", err)
+	}
+
+	taskId := t.Id
+	directBlockers, err := ctx.TaskTree().GetDirectBlockers(taskId)
+	if err != nil {
+		return "", fmt.Sprintf("failed to get blockers: %v", err)
+	}
+	allBlockers, err := ctx.TaskTree().GetAllBlockers(taskId)
+	if err != nil {
+		return "", fmt.Sprintf("failed to get blockers: %v", err)
+	}
+
+	if len(allBlockers) == 0 {
+		return fmt.Sprintf("task %v is not blocked", t.Alias), ""
+	}
+
+	directBlockerIds := util.Map(directBlockers, func(t task.Task) task.Id { return t.Id })
+	inheritedBlockers := util.Filter(allBlockers, func(t task.Task) bool {
+		return !util.Contains(directBlockerIds, t.Id)
+	})
+
+	parts := make([]string, 0, 2)
+	if len(directBlockers) > 0 {
+		parts = append(parts, "blocked by "+formatBlockers(directBlockers))
+	}
+	if len(inheritedBlockers) > 0 {
+		parts = append(parts, "inherited from ancestors: "+formatBlockers(inheritedBlockers))
+	}
+	return fmt.Sprintf("task %v is %v", t.Alias, strings.Join(parts, "; ")), ""
+}
+
+func (c BlockersCommand) Usage() string {
+	return "blockers <task>"
+}
+
+func (c BlockersCommand) Name() string {
+	return "blockers"
+}
+
+// formatBlockers lists blockers by alias, marking the ones that are already completed.
+func formatBlockers(blockers []task.Task) string {
+	return strings.Join(util.Map(blockers, func(t task.Task) string {
+		if t.Completed {
+			return fmt.Sprintf("%v (done)", t.Alias)
+		}
+		return t.Alias
+	}), ", ")
+}
@@ -0,0 +1,50 @@
+package command
+
+// Commands returns the built-in commands keyed by name.
+func Commands() map[string]Command {
+	commands := make(map[string]Command)
+	register := func(cmd Command) {
+		commands[cmd.Name()] = cmd
+	}
+
+	register(DeleteCommand{})
+	register(AddCommand{})
+	register(AddSubtaskCommand{})
+	register(MoveCommand{})
+	register(IndentCommand{})
+	register(OutdentCommand{})
+	register(ReorderCommand{})
+	register(SortCommand{})
+	register(RenameCommand{})
+	register(DescribeCommand{})
+	register(CompleteCommand{})
+	register(UncompleteCommand{})
+	register(CompletionPolicyCommand{})
+	register(PriorityCommand{})
+	register(TagCommand{})
+	register(UntagCommand{})
+	register(EstimateCommand{})
+	register(LogTimeCommand{})
+	register(StartCommand{})
+	register(StopCommand{})
+	register(PomodoroCommand{})
+	register(DueCommand{})
+	register(DeferCommand{})
+	register(RecurCommand{})
+	register(BlockCommand{})
+	register(UnblockCommand{})
+	register(BlockersCommand{})
+	register(CriticalPathCommand{})
+	register(ReportCommand{})
+	register(ListCommand{})
+	register(NextCommand{})
+	register(ShowCommand{})
+	register(FilterCommand{})
+	register(UndoCommand{})
+	register(RedoCommand{})
+	register(ExportCommand{})
+	register(ImportCommand{})
+	register(HelpCommand{Commands: &commands})
+
+	return commands
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+)
+
+type CompleteCommand struct {
+}
+
+func (c CompleteCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to complete task: %v", err)
+	}
+
+	changed, created, err := ctx.TaskTree().CompleteTask(t.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to complete task %v: %v", t.Alias, err)
+	}
+
+	return DescribeCompletion(t, changed, created), ""
+}
+
+func (c CompleteCommand) Usage() string {
+	return "complete <task>"
+}
+
+func (c CompleteCommand) Name() string {
+	return "complete"
+}
+
+type UncompleteCommand struct {
+}
+
+func (c UncompleteCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to uncomplete task: %v", err)
+	}
+
+	changed, err := ctx.TaskTree().ReopenTask(t.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to uncomplete task %v: %v", t.Alias, err)
+	}
+
+	return DescribeCompletion(t, changed, nil), ""
+}
+
+func (c UncompleteCommand) Usage() string {
+	return "uncomplete <task>"
+}
+
+func (c UncompleteCommand) Name() string {
+	return "uncomplete"
+}
+
+// DescribeCompletion describes the tasks whose completion changed after
+// completing or reopening a task, including the ones changed by the
+// completion policy, and the next instances created for recurring tasks.
+func DescribeCompletion(t task.Task, changed []task.Task, created []task.Task) string {
+	if len(changed) == 0 {
+		if t.Completed {
+			return fmt.Sprintf("task %v is already completed", t.Alias)
+		}
+		return fmt.Sprintf("task %v is already open", t.Alias)
+	}
+
+	describe := func(tasks []task.Task) string {
+		return strings.Join(util.Map(tasks, func(t task.Task) string {
+			return fmt.Sprintf("%v (%v)", t.Alias, t.Name)
+		}), ", ")
+	}
+	completed := util.Filter(changed, func(t task.Task) bool { return t.Completed })
+	reopened := util.Filter(changed, func(t task.Task) bool { return !t.Completed })
+
+	parts := make([]string, 0, 2)
+	if len(completed) > 0 {
+		parts = append(parts, "completed "+describe(completed))
+	}
+	if len(reopened) > 0 {
+		parts = append(parts, "reopened "+describe(reopened))
+	}
+	for _, instance := range created {
+		part := fmt.Sprintf("next instance %v (%v)", instance.Alias, instance.Name)
+		if !instance.Due.IsZero() {
+			part += " due " + describeDate(instance.Due)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+type CompletionPolicyCommand struct {
+}
+
+func (c CompletionPolicyCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	switch len(args) {
+	case 1:
+		return fmt.Sprintf("completion policy is %v", ctx.TaskTree().CompletionPolicy()), ""
+	case 2:
+	default:
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	policy, err := tasktree.ParseCompletionPolicy(args[1])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	if err := ctx.TaskTree().SetCompletionPolicy(policy); err != nil {
+		return "", fmt.Sprintf("failed to set completion policy: %v", err)
+	}
+
+	return fmt.Sprintf("completion policy set to %v", policy), ""
+}
+
+func (c CompletionPolicyCommand) Usage() string {
+	return "completion-policy [independent|auto-complete-parent|cascade-down|require-subtasks-complete]"
+}
+
+func (c CompletionPolicyCommand) Name() string {
+	return "completion-policy"
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+)
+
+type CriticalPathCommand struct {
+}
+
+func (c CriticalPathCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) > 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	if len(args) == 1 {
+		ctx.SetCriticalPathGoal("")
+		return "cleared critical path", ""
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to compute critical path: %v", err)
+	}
+
+	path, err := ctx.TaskTree().GetCriticalPath(t.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to compute critical path: %v", err)
+	}
+	ctx.SetCriticalPathGoal(t.Id)
+
+	lines := []string{fmt.Sprintf(
+		"critical path to %v (%v) takes %v: %v",
+		t.Alias, t.Name, path.Duration,
+		strings.Join(util.Map(path.Chain, func(t task.Task) string { return t.Alias }), " → "),
+	)}
+	for _, s := range path.Tasks {
+		line := fmt.Sprintf(
+			"%v %v: work %v, start %v-%v, slack %v",
+			s.Task.Alias, s.Task.Name, s.Work, s.EarliestStart, s.LatestStart, s.Slack,
+		)
+		if s.Critical() {
+			line += " (critical)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), ""
+}
+
+func (c CriticalPathCommand) Usage() string {
+	return "critical-path [<task>]"
+}
+
+func (c CriticalPathCommand) Name() string {
+	return "critical-path"
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"strings"
+	"time"
+)
+
+// noDate is the argument that clears a date or recurrence.
+const noDate = "none"
+
+// parseDateArg parses a date argument relative to today, or noDate which
+// gives the zero time.
+func parseDateArg(arg string) (time.Time, error) {
+	if arg == noDate {
+		return time.Time{}, nil
+	}
+	return task.ParseDate(arg, time.Now())
+}
+
+// describeDate formats a date for output, e.g. "fri (2026-10-23)".
+func describeDate(date time.Time) string {
+	relative := task.FormatDate(date, time.Now())
+	if absolute := date.Format(task.DateLayout); relative != absolute {
+		return fmt.Sprintf("%v (%v)", relative, absolute)
+	}
+	return relative
+}
+
+type DueCommand struct {
+}
+
+func (c DueCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	due, err := parseDateArg(args[2])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.Due = due
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to set due date: %v", err)
+	}
+
+	if t.Due.IsZero() {
+		return fmt.Sprintf("task %v has no due date", t.Alias), ""
+	}
+	return fmt.Sprintf("task %v is due %v", t.Alias, describeDate(t.Due)), ""
+}
+
+func (c DueCommand) Usage() string {
+	return "due <task> <date>|none"
+}
+
+func (c DueCommand) Name() string {
+	return "due"
+}
+
+type DeferCommand struct {
+}
+
+func (c DeferCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	start, err := parseDateArg(args[2])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.Start = start
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to defer task: %v", err)
+	}
+
+	if t.Start.IsZero() {
+		return fmt.Sprintf("task %v is no longer deferred", t.Alias), ""
+	}
+	return fmt.Sprintf("deferred task %v until %v", t.Alias, describeDate(t.Start)), ""
+}
+
+func (c DeferCommand) Usage() string {
+	return "defer <task> <date>|none"
+}
+
+func (c DeferCommand) Name() string {
+	return "defer"
+}
+
+type RecurCommand struct {
+}
+
+func (c RecurCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) < 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	var recurrence task.Recurrence
+	if rule := strings.Join(args[2:], " "); rule != noDate {
+		var err error
+		if recurrence, err = task.ParseRecurrence(rule); err != nil {
+			return "", err.Error()
+		}
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.Recurrence = recurrence.Anchored(t.RecurrenceAnchor())
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to set recurrence: %v", err)
+	}
+
+	if t.Recurrence.IsZero() {
+		return fmt.Sprintf("task %v no longer recurs", t.Alias), ""
+	}
+	return fmt.Sprintf("task %v recurs %v", t.Alias, t.Recurrence), ""
+}
+
+func (c RecurCommand) Usage() string {
+	return "recur <task> <rule>|none"
+}
+
+func (c RecurCommand) Name() string {
+	return "recur"
+}
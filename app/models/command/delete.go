@@ -4,29 +4,85 @@ import (
 	"fmt"
 	"github.com/carreter/tasktree-go/app"
 	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"strings"
 )
 
 type DeleteCommand struct {
 }
 
+// parseArgs resolves the task to delete and the policy chosen with a
+// --reparent, --promote or --cascade flag.
+func (c DeleteCommand) parseArgs(ctx *app.Context, args []string) (task.Task, tasktree.DeletePolicy, error) {
+	policy := tasktree.PromoteChildren
+	rest := make([]string, 0, len(args))
+	policySet := false
+	for _, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "--") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		parsed, err := tasktree.ParseDeletePolicy(strings.TrimPrefix(arg, "--"))
+		if err != nil {
+			return task.Task{}, policy, fmt.Errorf("unknown flag %v, usage: %v", arg, c.Usage())
+		}
+		if policySet && parsed != policy {
+			return task.Task{}, policy, fmt.Errorf("only one of --reparent, --promote and --cascade can be given")
+		}
+		policy, policySet = parsed, true
+	}
+
+	if len(rest) != 1 {
+		return task.Task{}, policy, fmt.Errorf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, rest[0])
+	return t, policy, err
+}
+
+func (c DeleteCommand) Confirmation(ctx *app.Context, args ...string) string {
+	t, policy, err := c.parseArgs(ctx, args)
+	if err != nil {
+		// The error is reported when running the command.
+		return ""
+	}
+
+	if policy == tasktree.Cascade {
+		subtree, _ := ctx.TaskTree().GetSubtree(t.Id)
+		if len(subtree) > 1 {
+			return fmt.Sprintf("delete %d tasks (%v %v and its subtasks)?", len(subtree), t.Alias, t.Name)
+		}
+	}
+	return fmt.Sprintf("delete 1 task (%v %v)?", t.Alias, t.Name)
+}
+
 func (c DeleteCommand) Run(ctx *app.Context, args ...string) (string, string) {
-	if len(args) != 2 {
-		return "", fmt.Sprintf("inccorect number of arguments, usage: %v", c.Usage())
+	t, policy, err := c.parseArgs(ctx, args)
+	if err != nil {
+		return "", fmt.Sprintf("failed to delete task: %v", err)
 	}
 
-	taskId := task.Id(args[1])
-	err := ctx.TaskTree().DeleteTask(taskId)
+	subtree, err := ctx.TaskTree().GetSubtree(t.Id)
 	if err != nil {
 		return "", fmt.Sprintf("failed to delete task: %v", err)
 	}
 
-	return fmt.Sprintf("deleted task %v", taskId), ""
+	err = ctx.TaskTree().DeleteTaskWithPolicy(t.Id, policy)
+	if err != nil {
+		return "", fmt.Sprintf("failed to delete task: %v", err)
+	}
+
+	if policy == tasktree.Cascade && len(subtree) > 1 {
+		return fmt.Sprintf("deleted task %v (%v) and %d subtasks", t.Alias, t.Name, len(subtree)-1), ""
+	}
+	return fmt.Sprintf("deleted task %v (%v)", t.Alias, t.Name), ""
 }
 
 func (c DeleteCommand) Usage() string {
-	return "add <task name> [<task description>]"
+	return "delete [--reparent|--promote|--cascade] <task>"
 }
 
 func (c DeleteCommand) Name() string {
-	return "add"
+	return "delete"
 }
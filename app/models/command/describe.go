@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"strings"
+)
+
+type DescribeCommand struct {
+}
+
+func (c DescribeCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) < 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	description := strings.Join(args[2:], " ")
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.Description = description
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to describe task: %v", err)
+	}
+
+	if description == "" {
+		return fmt.Sprintf("cleared description of task %v", t.Alias), ""
+	}
+	return fmt.Sprintf("updated description of task %v", t.Alias), ""
+}
+
+func (c DescribeCommand) Usage() string {
+	return "describe <task> [<description>]"
+}
+
+func (c DescribeCommand) Name() string {
+	return "describe"
+}
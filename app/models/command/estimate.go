@@ -0,0 +1,52 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"time"
+)
+
+// parseDuration parses a duration argument, rejecting negative durations.
+func parseDuration(arg string) (time.Duration, error) {
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected e.g. 1h30m", arg)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration %v must not be negative", d)
+	}
+	return d, nil
+}
+
+type EstimateCommand struct {
+}
+
+func (c EstimateCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	estimate, err := parseDuration(args[2])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.EstimatedTime = estimate
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to set estimate: %v", err)
+	}
+
+	return fmt.Sprintf("estimated task %v at %v", t.Alias, t.EstimatedTime), ""
+}
+
+func (c EstimateCommand) Usage() string {
+	return "estimate <task> <duration>"
+}
+
+func (c EstimateCommand) Name() string {
+	return "estimate"
+}
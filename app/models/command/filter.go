@@ -0,0 +1,35 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/query"
+	"strings"
+)
+
+type FilterCommand struct {
+}
+
+func (c FilterCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) == 1 {
+		ctx.SetFilter("", nil)
+		return "cleared filter", ""
+	}
+
+	rawQuery := strings.Join(args[1:], " ")
+	filter, err := query.Parse(rawQuery)
+	if err != nil {
+		return "", fmt.Sprintf("invalid query: %v", err)
+	}
+	ctx.SetFilter(rawQuery, filter)
+
+	return fmt.Sprintf("%d matching tasks", len(query.Filter(ctx.TaskTree(), filter))), ""
+}
+
+func (c FilterCommand) Usage() string {
+	return "filter [<query>]"
+}
+
+func (c FilterCommand) Name() string {
+	return "filter"
+}
@@ -3,6 +3,8 @@ package command
 import (
 	"fmt"
 	"github.com/carreter/tasktree-go/app"
+	"sort"
+	"strings"
 )
 
 type HelpCommand struct {
@@ -10,8 +12,16 @@ type HelpCommand struct {
 }
 
 func (c HelpCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) == 1 {
+		names := make([]string, 0, len(*c.Commands))
+		for name := range *c.Commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("commands: %v", strings.Join(names, ", ")), ""
+	}
 	if len(args) != 2 {
-		return "", fmt.Sprintf("inccorect number of arguments, usage: %v", c.Usage())
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
 	}
 
 	cmd, exists := (*c.Commands)[args[1]]
@@ -23,7 +33,7 @@ func (c HelpCommand) Run(ctx *app.Context, args ...string) (string, string) {
 }
 
 func (c HelpCommand) Usage() string {
-	return "help <command>"
+	return "help [<command>]"
 }
 
 func (c HelpCommand) Name() string {
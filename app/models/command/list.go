@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/query"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"strings"
+)
+
+type ListCommand struct {
+}
+
+func (c ListCommand) RunData(ctx *app.Context, args ...string) (any, error) {
+	taskTree := ctx.TaskTree()
+	if len(args) > 1 {
+		filter, err := query.Parse(strings.Join(args[1:], " "))
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		return c.filteredInfos(taskTree, filter)
+	}
+
+	infos := make([]TaskInfo, 0)
+	var visit func(t task.Task) error
+	visit = func(t task.Task) error {
+		info, err := newTaskInfo(taskTree, t)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, info)
+
+		subtasks, err := taskTree.GetDirectSubtasksOf(t.Id)
+		if err != nil {
+			return err
+		}
+		for _, subtask := range subtasks {
+			if err := visit(subtask); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range taskTree.GetRootTasks() {
+		if err := visit(root); err != nil {
+			return nil, err
+		}
+	}
+
+	return infos, nil
+}
+
+func (c ListCommand) filteredInfos(taskTree *tasktree.TaskTree, filter query.Expr) ([]TaskInfo, error) {
+	matches := query.Filter(taskTree, filter)
+	infos := make([]TaskInfo, len(matches))
+	for i, match := range matches {
+		info, err := newTaskInfo(taskTree, match)
+		if err != nil {
+			return nil, err
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+func (c ListCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	data, err := c.RunData(ctx, args...)
+	if err != nil {
+		return "", err.Error()
+	}
+	infos := data.([]TaskInfo)
+
+	if len(infos) == 0 {
+		return "no tasks", ""
+	}
+
+	// Filtered results aren't shown as an outline since their ancestors may be missing.
+	filtered := len(args) > 1
+
+	lines := make([]string, len(infos))
+	for i, info := range infos {
+		checkbox := "[ ]"
+		if info.Completed {
+			checkbox = "[x]"
+		}
+		indent := ""
+		if !filtered {
+			indent = strings.Repeat("  ", info.Depth)
+		}
+		line := fmt.Sprintf("%v%v %v %v", indent, checkbox, info.Alias, info.Name)
+		if info.Due != "" && !info.Completed {
+			if info.Overdue {
+				line += fmt.Sprintf(" [overdue, due %v]", info.Due)
+			} else {
+				line += fmt.Sprintf(" [due %v]", info.Due)
+			}
+		}
+		if info.Blocked && !info.Completed {
+			line += " [blocked]"
+		}
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n"), ""
+}
+
+func (c ListCommand) Usage() string {
+	return "list [<query>]"
+}
+
+func (c ListCommand) Name() string {
+	return "list"
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"time"
+)
+
+type LogTimeCommand struct {
+}
+
+func (c LogTimeCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	logged, err := parseDuration(args[2])
+	if err != nil {
+		return "", err.Error()
+	}
+	if logged == 0 {
+		return "", "logged time must be greater than zero"
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to log time: %v", err)
+	}
+	// The time is logged as a session that just ended.
+	now := time.Now().Truncate(time.Second)
+	if err := ctx.TaskTree().LogTime(t.Id, now.Add(-logged), now); err != nil {
+		return "", fmt.Sprintf("failed to log time: %v", err)
+	}
+	t, _ = ctx.TaskTree().GetTask(t.Id)
+
+	return fmt.Sprintf("logged %v on task %v (%v total)", logged, t.Alias, t.TimeInvested), ""
+}
+
+func (c LogTimeCommand) Usage() string {
+	return "log-time <task> <duration>"
+}
+
+func (c LogTimeCommand) Name() string {
+	return "log-time"
+}
@@ -11,12 +11,23 @@ import (
 	"unicode"
 )
 
+// A Command is an action that can be run from the command bar or as a
+// tasktree-cli subcommand. Run returns an output message and an error message,
+// at most one of which is non-empty.
 type Command interface {
 	Run(*app.Context, ...string) (string, string)
 	Usage() string
 	Name() string
 }
 
+// A ConfirmCommand is a Command that asks for confirmation before being run
+// from the command bar. Confirmation returns the question to ask, or an empty
+// string if the command can run without confirmation.
+type ConfirmCommand interface {
+	Command
+	Confirmation(*app.Context, ...string) string
+}
+
 type Model struct {
 	ctx *app.Context
 
@@ -25,6 +36,11 @@ type Model struct {
 	errorMsg  string
 	outMsg    string
 
+	// pending is the command waiting for confirmation, if any.
+	pending      ConfirmCommand
+	pendingArgs  []string
+	confirmation string
+
 	commands map[string]Command
 }
 
@@ -33,12 +49,8 @@ func New(ctx *app.Context) Model {
 	m := Model{
 		textInput: textInput,
 		ctx:       ctx,
-		commands:  make(map[string]Command),
+		commands:  Commands(),
 	}
-	m.RegisterCommand(DeleteCommand{})
-	m.RegisterCommand(AddCommand{})
-	m.RegisterCommand(AddSubtaskCommand{})
-	m.RegisterCommand(HelpCommand{Commands: &m.commands})
 
 	return m
 }
@@ -48,6 +60,12 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && m.pending != nil {
+		m.confirm(msg.String() == "y" || msg.String() == "Y")
+		m.Focus()
+		return m, nil
+	}
+
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -55,6 +73,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			cmd = m.CallCommand()
 			m.textInput.Reset()
+			if m.pending != nil {
+				// Stay focused until the command is confirmed or cancelled.
+				return m, cmd
+			}
 			m.Focus()
 		case "esc":
 			m.textInput.Reset()
@@ -71,13 +93,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	if m.pending != nil {
+		return m.textInput.PromptStyle.Bold(true).Render(m.confirmation + " [y/N]")
+	}
 	if !m.focused {
 		m.textInput.Prompt = ""
 		if m.errorMsg != "" {
 			m.textInput.Placeholder = "error: " + m.errorMsg
 			m.textInput.PlaceholderStyle = m.textInput.PlaceholderStyle.Bold(true).Foreground(lipgloss.Color("1"))
 		} else if m.outMsg != "" {
-			m.textInput.Placeholder = m.outMsg
+			m.textInput.Placeholder = strings.ReplaceAll(m.outMsg, "\n", " | ")
 		} else {
 			m.textInput.Placeholder = "Type \":\" to enter command mode"
 		}
@@ -97,6 +122,22 @@ func (m *Model) Focus() tea.Cmd {
 	return m.textInput.Focus()
 }
 
+// SetValue sets the text in the command bar, placing the cursor at the end.
+func (m *Model) SetValue(value string) {
+	m.textInput.SetValue(value)
+	m.textInput.CursorEnd()
+}
+
+// SetError displays an error message in the command bar.
+func (m *Model) SetError(msg string) {
+	m.errorMsg = msg
+}
+
+// SetStatus displays the outcome of an action in the command bar.
+func (m *Model) SetStatus(outMsg string, errorMsg string) {
+	m.outMsg, m.errorMsg = outMsg, errorMsg
+}
+
 func (m *Model) CallCommand() tea.Cmd {
 	args := parseRawArgs(m.textInput.Value())
 
@@ -115,11 +156,33 @@ func (m *Model) CallCommand() tea.Cmd {
 		return nil
 	}
 
+	if confirmCommand, ok := command.(ConfirmCommand); ok {
+		if confirmation := confirmCommand.Confirmation(m.ctx, args...); confirmation != "" {
+			m.pending, m.pendingArgs, m.confirmation = confirmCommand, args, confirmation
+			return nil
+		}
+	}
+
 	m.outMsg, m.errorMsg = command.Run(m.ctx, args...)
 
 	return nil
 }
 
+// Confirming returns whether the command bar is waiting for a command to be confirmed.
+func (m *Model) Confirming() bool {
+	return m.pending != nil
+}
+
+// confirm runs or cancels the command waiting for confirmation.
+func (m *Model) confirm(confirmed bool) {
+	if confirmed {
+		m.outMsg, m.errorMsg = m.pending.Run(m.ctx, m.pendingArgs...)
+	} else {
+		m.outMsg, m.errorMsg = "cancelled", ""
+	}
+	m.pending, m.pendingArgs, m.confirmation = nil, nil, ""
+}
+
 func (m *Model) RegisterCommand(cmd Command) {
 	m.commands[cmd.Name()] = cmd
 }
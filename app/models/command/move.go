@@ -0,0 +1,129 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"strconv"
+)
+
+// rootRef is the reference used by move for the top level of the tree.
+const rootRef = "root"
+
+type MoveCommand struct {
+}
+
+func (c MoveCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 && len(args) != 4 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to move task: %v", err)
+	}
+
+	var newParent task.Task
+	if args[2] != rootRef {
+		newParent, err = resolveTask(ctx, args[2])
+		if err != nil {
+			return "", fmt.Sprintf("failed to move task: %v", err)
+		}
+	}
+
+	// Positions are 1-based for users, the last position is used by default.
+	position := -1
+	if len(args) == 4 {
+		position, err = strconv.Atoi(args[3])
+		if err != nil || position < 1 {
+			return "", fmt.Sprintf("invalid position %q, expected a number starting at 1", args[3])
+		}
+		position--
+	}
+
+	err = ctx.TaskTree().MoveTask(t.Id, newParent.Id, position)
+	if err != nil {
+		return "", fmt.Sprintf("failed to move task: %v", err)
+	}
+
+	return describeMove(ctx, t), ""
+}
+
+func (c MoveCommand) Usage() string {
+	return "move <task> <new parent task>|root [<position>]"
+}
+
+func (c MoveCommand) Name() string {
+	return "move"
+}
+
+type IndentCommand struct {
+}
+
+func (c IndentCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to indent task: %v", err)
+	}
+
+	err = ctx.TaskTree().IndentTask(t.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to indent task: %v", err)
+	}
+
+	return describeMove(ctx, t), ""
+}
+
+func (c IndentCommand) Usage() string {
+	return "indent <task>"
+}
+
+func (c IndentCommand) Name() string {
+	return "indent"
+}
+
+type OutdentCommand struct {
+}
+
+func (c OutdentCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to outdent task: %v", err)
+	}
+
+	err = ctx.TaskTree().OutdentTask(t.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to outdent task: %v", err)
+	}
+
+	return describeMove(ctx, t), ""
+}
+
+func (c OutdentCommand) Usage() string {
+	return "outdent <task>"
+}
+
+func (c OutdentCommand) Name() string {
+	return "outdent"
+}
+
+// describeMove describes where a task ended up after being moved.
+func describeMove(ctx *app.Context, t task.Task) string {
+	parentId, index, err := ctx.TaskTree().GetPosition(t.Id)
+	if err != nil {
+		return fmt.Sprintf("moved task %v", t.Alias)
+	}
+	if parentId == "" {
+		return fmt.Sprintf("moved task %v to position %d of the root tasks", t.Alias, index+1)
+	}
+	parent, _ := ctx.TaskTree().GetTask(parentId)
+	return fmt.Sprintf("moved task %v to position %d under task %v", t.Alias, index+1, parent.Alias)
+}
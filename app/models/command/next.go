@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type NextCommand struct {
+}
+
+func (c NextCommand) RunData(ctx *app.Context, args ...string) (any, error) {
+	if len(args) > 2 {
+		return nil, fmt.Errorf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	next := ctx.TaskTree().GetNextActions()
+	if len(args) == 2 {
+		limit, err := strconv.Atoi(args[1])
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid number of tasks %q", args[1])
+		}
+		next = next[:min(limit, len(next))]
+	}
+
+	infos := make([]TaskInfo, len(next))
+	for i, t := range next {
+		info, err := newTaskInfo(ctx.TaskTree(), t)
+		if err != nil {
+			return nil, err
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+func (c NextCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	data, err := c.RunData(ctx, args...)
+	if err != nil {
+		return "", err.Error()
+	}
+	infos := data.([]TaskInfo)
+
+	if len(infos) == 0 {
+		return "nothing to do right now", ""
+	}
+
+	lines := make([]string, len(infos))
+	for i, info := range infos {
+		line := fmt.Sprintf("%d. %v %v", i+1, info.Alias, info.Name)
+		if info.Priority != task.Default.String() {
+			line += fmt.Sprintf(" [%v]", info.Priority)
+		}
+		if ancestors, _ := ctx.TaskTree().GetAncestorTasks(info.Id); len(ancestors) > 0 {
+			names := util.Map(ancestors, func(t task.Task) string { return t.Name })
+			slices.Reverse(names)
+			line += " (" + strings.Join(names, " › ") + ")"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), ""
+}
+
+func (c NextCommand) Usage() string {
+	return "next [<number of tasks>]"
+}
+
+func (c NextCommand) Name() string {
+	return "next"
+}
@@ -0,0 +1,90 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+)
+
+type ReorderCommand struct {
+}
+
+func (c ReorderCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to reorder task: %v", err)
+	}
+
+	var reorder func(id task.Id) error
+	switch args[2] {
+	case "up":
+		reorder = ctx.TaskTree().MoveUp
+	case "down":
+		reorder = ctx.TaskTree().MoveDown
+	case "top":
+		reorder = ctx.TaskTree().MoveToTop
+	case "bottom":
+		reorder = ctx.TaskTree().MoveToBottom
+	default:
+		return "", fmt.Sprintf("unknown direction %q, expected one of: up, down, top, bottom", args[2])
+	}
+
+	if err := reorder(t.Id); err != nil {
+		return "", fmt.Sprintf("failed to reorder task: %v", err)
+	}
+
+	return describeMove(ctx, t), ""
+}
+
+func (c ReorderCommand) Usage() string {
+	return "reorder <task> up|down|top|bottom"
+}
+
+func (c ReorderCommand) Name() string {
+	return "reorder"
+}
+
+type SortCommand struct {
+}
+
+func (c SortCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	var parent task.Task
+	if args[1] != rootRef {
+		var err error
+		parent, err = resolveTask(ctx, args[1])
+		if err != nil {
+			return "", fmt.Sprintf("failed to sort subtasks: %v", err)
+		}
+	}
+
+	key, err := tasktree.ParseSortKey(args[2])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	if err := ctx.TaskTree().SortSubtasks(parent.Id, key); err != nil {
+		return "", fmt.Sprintf("failed to sort subtasks: %v", err)
+	}
+
+	if parent.Id == "" {
+		return fmt.Sprintf("sorted root tasks by %v", key), ""
+	}
+	return fmt.Sprintf("sorted subtasks of task %v by %v", parent.Alias, key), ""
+}
+
+func (c SortCommand) Usage() string {
+	return "sort <parent task>|root priority|name|estimate"
+}
+
+func (c SortCommand) Name() string {
+	return "sort"
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"strconv"
+	"time"
+)
+
+type PomodoroCommand struct {
+}
+
+// parseSettings applies the interval length flags among the arguments to the
+// current settings.
+func (c PomodoroCommand) parseSettings(ctx *app.Context, args []string) (app.PomodoroSettings, []string, error) {
+	settings := ctx.PomodoroSettings()
+	durations := []struct {
+		flag  string
+		value *time.Duration
+	}{
+		{"work", &settings.Work},
+		{"break", &settings.ShortBreak},
+		{"long-break", &settings.LongBreak},
+	}
+	for _, d := range durations {
+		arg, rest, err := parseFlag(args, d.flag, "")
+		if err != nil {
+			return settings, nil, err
+		}
+		args = rest
+		if arg == "" {
+			continue
+		}
+		if *d.value, err = parseDuration(arg); err != nil {
+			return settings, nil, err
+		}
+		if *d.value < time.Second {
+			return settings, nil, fmt.Errorf("--%v must be at least a second", d.flag)
+		}
+	}
+
+	arg, args, err := parseFlag(args, "long-break-every", "")
+	if err != nil {
+		return settings, nil, err
+	}
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return settings, nil, fmt.Errorf("invalid number of work intervals %q", arg)
+		}
+		settings.LongBreakEvery = n
+	}
+	return settings, args, nil
+}
+
+func (c PomodoroCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	settings, args, err := c.parseSettings(ctx, args)
+	if err != nil {
+		return "", err.Error()
+	}
+	if len(args) > 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+	ctx.SetPomodoroSettings(settings)
+
+	pomodoro := ctx.Pomodoro()
+	if len(args) == 1 {
+		if pomodoro == nil {
+			return "pomodoro settings: " + describePomodoroSettings(settings), ""
+		}
+		// The running pomodoro uses the new lengths too, from its current
+		// interval on.
+		pomodoro.Settings = settings
+		return describePomodoro(ctx, pomodoro, time.Now()), ""
+	}
+
+	if args[1] == "stop" {
+		if pomodoro == nil {
+			return "", "no pomodoro is running"
+		}
+		ctx.SetPomodoro(nil)
+		return describeStoppedPomodoro(ctx, pomodoro), ""
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to start pomodoro: %v", err)
+	}
+
+	// Work intervals are logged as they end, so the timer would log the same
+	// time twice.
+	out := ""
+	stopped, err := ctx.TaskTree().StopTimer()
+	if err == nil {
+		out = describeStoppedTimer(ctx, stopped) + "; "
+	} else if !errors.Is(err, tasktree.ErrNoActiveTimer) {
+		return "", fmt.Sprintf("failed to stop timer: %v", err)
+	}
+
+	ctx.SetPomodoro(app.NewPomodoro(settings, t.Id, time.Now().Truncate(time.Second)))
+	return out + fmt.Sprintf("started pomodoro on task %v (%v): %v", t.Alias, t.Name, describePomodoroSettings(settings)), ""
+}
+
+// describePomodoroSettings describes the lengths of pomodoro intervals, e.g.
+// "25m0s work, 5m0s breaks, 15m0s long break every 4 work intervals".
+func describePomodoroSettings(settings app.PomodoroSettings) string {
+	description := fmt.Sprintf("%v work, %v breaks", settings.Work, settings.ShortBreak)
+	if settings.LongBreakEvery > 0 {
+		description += fmt.Sprintf(", %v long break every %v", settings.LongBreak, pluralize(settings.LongBreakEvery, "work interval"))
+	}
+	return description
+}
+
+// describeStoppedPomodoro describes a pomodoro that was just stopped.
+func describeStoppedPomodoro(ctx *app.Context, pomodoro *app.Pomodoro) string {
+	t, _ := ctx.TaskTree().GetTask(pomodoro.TaskId)
+	return fmt.Sprintf("stopped pomodoro on task %v after %v", t.Alias, pluralize(pomodoro.Completed, "work interval"))
+}
+
+// describePomodoro describes the current interval of a running pomodoro.
+func describePomodoro(ctx *app.Context, pomodoro *app.Pomodoro, now time.Time) string {
+	t, _ := ctx.TaskTree().GetTask(pomodoro.TaskId)
+	return fmt.Sprintf(
+		"pomodoro on task %v (%v): %v left of %v, %v completed",
+		t.Alias, t.Name, pomodoro.Remaining(now).Truncate(time.Second), pomodoro.Phase, pluralize(pomodoro.Completed, "work interval"),
+	)
+}
+
+// DescribePomodoroAdvance describes a pomodoro that just moved on to another
+// interval, having logged the given time from the work interval that ended.
+func DescribePomodoroAdvance(ctx *app.Context, pomodoro *app.Pomodoro, logged time.Duration) string {
+	t, _ := ctx.TaskTree().GetTask(pomodoro.TaskId)
+	if pomodoro.Phase == app.WorkPhase {
+		return fmt.Sprintf("break is over, back to work on task %v for %v", t.Alias, pomodoro.PhaseLength())
+	}
+	total, _ := ctx.TaskTree().GetPomodoroCount(t.Id)
+	return fmt.Sprintf(
+		"logged %v on task %v (%v total), take a %v of %v",
+		logged, t.Alias, pluralize(total, "pomodoro"), pomodoro.Phase, pomodoro.PhaseLength(),
+	)
+}
+
+func (c PomodoroCommand) Usage() string {
+	return "pomodoro [<task>|stop] [--work <duration>] [--break <duration>] [--long-break <duration>] [--long-break-every <number>]"
+}
+
+func (c PomodoroCommand) Name() string {
+	return "pomodoro"
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+)
+
+type PriorityCommand struct {
+}
+
+func (c PriorityCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	priority, err := task.ParsePriority(args[2])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.Priority = priority
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to set priority: %v", err)
+	}
+
+	return fmt.Sprintf("set priority of task %v to %v", t.Alias, t.Priority), ""
+}
+
+func (c PriorityCommand) Usage() string {
+	return "priority <task> <default|urgent|high|normal|low>"
+}
+
+func (c PriorityCommand) Name() string {
+	return "priority"
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"strings"
+)
+
+type RenameCommand struct {
+}
+
+func (c RenameCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) < 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	name := strings.Join(args[2:], " ")
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		t.Name = name
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to rename task: %v", err)
+	}
+
+	return fmt.Sprintf("renamed task %v to %q", t.Alias, t.Name), ""
+}
+
+func (c RenameCommand) Usage() string {
+	return "rename <task> <new name>"
+}
+
+func (c RenameCommand) Name() string {
+	return "rename"
+}
@@ -0,0 +1,295 @@
+package command
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultReportDays is the number of days, up to and including today, that a
+// report covers if no range is given.
+const defaultReportDays = 7
+
+// ReportInfo is the structured representation of a time report returned by
+// ReportCommand.
+type ReportInfo struct {
+	Grouping string `json:"grouping"`
+	// From and To are the first and last day of the report, empty if unbounded.
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Rows     []ReportRowInfo `json:"rows"`
+	Total    string          `json:"total"`
+	Overruns []OverrunInfo   `json:"overruns"`
+}
+
+// ReportRowInfo is one group of a ReportInfo.
+type ReportRowInfo struct {
+	Key      string `json:"key"`
+	Logged   string `json:"logged"`
+	Tasks    int    `json:"tasks"`
+	Estimate string `json:"estimate"`
+	Invested string `json:"invested"`
+	Overrun  bool   `json:"overrun"`
+}
+
+// OverrunInfo is a task that took longer than estimated.
+type OverrunInfo struct {
+	Id       task.Id `json:"id"`
+	Alias    string  `json:"alias"`
+	Name     string  `json:"name"`
+	Estimate string  `json:"estimate"`
+	Invested string  `json:"invested"`
+	Excess   string  `json:"excess"`
+}
+
+func newReportInfo(report tasktree.Report) ReportInfo {
+	from, to := reportDays(report)
+	info := ReportInfo{
+		Grouping: report.Grouping.String(),
+		From:     from,
+		To:       to,
+		Rows:     make([]ReportRowInfo, len(report.Rows)),
+		Total:    report.Total.String(),
+		Overruns: make([]OverrunInfo, len(report.Overruns)),
+	}
+	for i, row := range report.Rows {
+		info.Rows[i] = ReportRowInfo{
+			Key:      row.Key,
+			Logged:   row.Logged.String(),
+			Tasks:    row.Tasks,
+			Estimate: row.Estimate.String(),
+			Invested: row.Invested.String(),
+			Overrun:  row.Overrun(),
+		}
+	}
+	for i, o := range report.Overruns {
+		info.Overruns[i] = OverrunInfo{
+			Id:       o.Task.Id,
+			Alias:    o.Task.Alias,
+			Name:     o.Task.Name,
+			Estimate: o.Task.EstimatedTime.String(),
+			Invested: o.Task.TimeInvested.String(),
+			Excess:   o.Excess.String(),
+		}
+	}
+	return info
+}
+
+// reportDays returns the first and last day covered by a report, empty if the
+// range is unbounded on that side.
+func reportDays(report tasktree.Report) (string, string) {
+	var from, to string
+	if !report.From.IsZero() {
+		from = report.From.Format(task.DateLayout)
+	}
+	if !report.To.IsZero() {
+		// To is exclusive, so the last day is the one before it.
+		to = report.To.Add(-time.Nanosecond).Format(task.DateLayout)
+	}
+	return from, to
+}
+
+// DescribeReport summarizes the grouping, range and total of a report, e.g.
+// "time by tag from 2026-10-12 to 2026-10-18: 6h30m0s".
+func DescribeReport(report tasktree.Report) string {
+	from, to := reportDays(report)
+	switch {
+	case from != "" && to != "":
+		return fmt.Sprintf("time by %v from %v to %v: %v", report.Grouping, from, to, report.Total)
+	case from != "":
+		return fmt.Sprintf("time by %v since %v: %v", report.Grouping, from, report.Total)
+	case to != "":
+		return fmt.Sprintf("time by %v until %v: %v", report.Grouping, to, report.Total)
+	default:
+		return fmt.Sprintf("time by %v: %v", report.Grouping, report.Total)
+	}
+}
+
+// RenderReportTable renders the rows of a report as a table, followed by the
+// tasks that overran their estimates.
+func RenderReportTable(report tasktree.Report) string {
+	overrunStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		Headers(report.Grouping.String(), "logged", "tasks", "estimate", "invested").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return cellStyle.Bold(true)
+			}
+			if row <= len(report.Rows) && report.Rows[row-1].Overrun() {
+				return cellStyle.Inherit(overrunStyle)
+			}
+			return cellStyle
+		})
+	for _, row := range report.Rows {
+		t.Row(row.Key, row.Logged.String(), strconv.Itoa(row.Tasks), row.Estimate.String(), row.Invested.String())
+	}
+
+	lines := []string{t.Render()}
+	if len(report.Overruns) > 0 {
+		lines = append(lines, "Over estimate:")
+		for _, o := range report.Overruns {
+			lines = append(lines, overrunStyle.Render(describeOverrun(o)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeOverrun describes a task that overran its estimate, e.g.
+// "t3 (write report) +1h0m0s (3h0m0s of 2h0m0s)".
+func describeOverrun(o tasktree.Overrun) string {
+	return fmt.Sprintf("%v (%v) +%v (%v of %v)", o.Task.Alias, o.Task.Name, o.Excess, o.Task.TimeInvested, o.Task.EstimatedTime)
+}
+
+type ReportCommand struct {
+}
+
+// report parses the arguments of the command and computes the report, along
+// with the output format.
+func (c ReportCommand) report(ctx *app.Context, args []string) (tasktree.Report, string, error) {
+	outputFormat, args, err := parseFlag(args, "format", "text")
+	if err != nil {
+		return tasktree.Report{}, "", err
+	}
+	if outputFormat != "table" && outputFormat != "text" && outputFormat != "csv" {
+		return tasktree.Report{}, "", fmt.Errorf("unknown format %q, expected one of: csv, table, text", outputFormat)
+	}
+
+	today := task.StartOfDay(time.Now())
+	fromArg, args, err := parseFlag(args, "from", today.AddDate(0, 0, 1-defaultReportDays).Format(task.DateLayout))
+	if err != nil {
+		return tasktree.Report{}, "", err
+	}
+	toArg, args, err := parseFlag(args, "to", today.Format(task.DateLayout))
+	if err != nil {
+		return tasktree.Report{}, "", err
+	}
+	if len(args) > 2 {
+		return tasktree.Report{}, "", fmt.Errorf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	grouping := tasktree.GroupByDay
+	if len(args) == 2 {
+		if grouping, err = tasktree.ParseReportGrouping(args[1]); err != nil {
+			return tasktree.Report{}, "", err
+		}
+	}
+	from, err := parseDateArg(fromArg)
+	if err != nil {
+		return tasktree.Report{}, "", err
+	}
+	to, err := parseDateArg(toArg)
+	if err != nil {
+		return tasktree.Report{}, "", err
+	}
+	if !to.IsZero() {
+		// The last day is included in the report.
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return tasktree.Report{}, "", fmt.Errorf("report must end on or after the day it starts")
+	}
+
+	report, err := ctx.TaskTree().GetReport(grouping, from, to)
+	return report, outputFormat, err
+}
+
+func (c ReportCommand) RunData(ctx *app.Context, args ...string) (any, error) {
+	report, _, err := c.report(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newReportInfo(report), nil
+}
+
+func (c ReportCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	report, outputFormat, err := c.report(ctx, args)
+	if err != nil {
+		return "", fmt.Sprintf("failed to create report: %v", err)
+	}
+	if ctx.Interactive() {
+		// The interactive interface shows the report in place of the tree.
+		ctx.SetReport(&report)
+	}
+
+	switch outputFormat {
+	case "table":
+		return DescribeReport(report) + "\n" + RenderReportTable(report), ""
+	case "csv":
+		out, err := reportCSV(report)
+		if err != nil {
+			return "", fmt.Sprintf("failed to create report: %v", err)
+		}
+		return out, ""
+	}
+
+	lines := []string{DescribeReport(report)}
+	keyWidth := 0
+	for _, row := range report.Rows {
+		keyWidth = max(keyWidth, lipgloss.Width(row.Key))
+	}
+	for _, row := range report.Rows {
+		line := fmt.Sprintf("%-*v  %v (%v", keyWidth, row.Key, row.Logged, pluralize(row.Tasks, "task"))
+		if row.Estimate > 0 {
+			line += fmt.Sprintf(", %v invested of %v estimated", row.Invested, row.Estimate)
+		}
+		line += ")"
+		if row.Overrun() {
+			line += " (over estimate)"
+		}
+		lines = append(lines, line)
+	}
+	if len(report.Overruns) > 0 {
+		lines = append(lines, "over estimate:")
+		for _, o := range report.Overruns {
+			lines = append(lines, "  "+describeOverrun(o))
+		}
+	}
+	return strings.Join(lines, "\n"), ""
+}
+
+// pluralize formats a count of things, e.g. "1 task" or "2 tasks".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %v", n, noun)
+	}
+	return fmt.Sprintf("%d %vs", n, noun)
+}
+
+// reportCSV formats the rows of a report as CSV with a header. Durations are
+// given in minutes so that spreadsheets can add them up.
+func reportCSV(report tasktree.Report) (string, error) {
+	minutes := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Minutes(), 'f', -1, 64)
+	}
+
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	records := [][]string{{report.Grouping.String(), "logged_minutes", "tasks", "estimate_minutes", "invested_minutes", "overrun"}}
+	for _, row := range report.Rows {
+		records = append(records, []string{
+			row.Key, minutes(row.Logged), strconv.Itoa(row.Tasks), minutes(row.Estimate), minutes(row.Invested), strconv.FormatBool(row.Overrun()),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+func (c ReportCommand) Usage() string {
+	return "report [day|week|tag|priority|subtree] [--from <date>] [--to <date>] [--format text|table|csv]"
+}
+
+func (c ReportCommand) Name() string {
+	return "report"
+}
@@ -0,0 +1,225 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+	"time"
+)
+
+// A DataCommand is a Command whose result can also be returned as structured
+// data, e.g. to be printed as JSON by tasktree-cli.
+type DataCommand interface {
+	Command
+	RunData(*app.Context, ...string) (any, error)
+}
+
+// TaskInfo is the structured representation of a task returned by DataCommands.
+type TaskInfo struct {
+	Id            task.Id    `json:"id"`
+	Alias         string     `json:"alias"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description,omitempty"`
+	Completed     bool       `json:"completed"`
+	Priority      string     `json:"priority"`
+	Tags          []string   `json:"tags"`
+	EstimatedTime string     `json:"estimated_time"`
+	TimeInvested  string     `json:"time_invested"`
+	Pomodoros     int        `json:"pomodoros"`
+	Due           string     `json:"due,omitempty"`
+	Start         string     `json:"start,omitempty"`
+	CompletedAt   string     `json:"completed_at,omitempty"`
+	Recurrence    string     `json:"recurrence,omitempty"`
+	Overdue       bool       `json:"overdue"`
+	Parent        task.Id    `json:"parent,omitempty"`
+	Subtasks      []task.Id  `json:"subtasks"`
+	BlockedBy     []task.Id  `json:"blocked_by"`
+	Blocked       bool       `json:"blocked"`
+	Depth         int        `json:"depth"`
+	Rollup        RollupInfo `json:"rollup"`
+}
+
+// RollupInfo is the structured representation of a tasktree.Rollup.
+type RollupInfo struct {
+	EstimatedTime     string  `json:"estimated_time"`
+	TimeInvested      string  `json:"time_invested"`
+	RemainingTime     string  `json:"remaining_time"`
+	Tasks             int     `json:"tasks"`
+	CompletedTasks    int     `json:"completed_tasks"`
+	PercentByCount    float64 `json:"percent_by_count"`
+	PercentByEstimate float64 `json:"percent_by_estimate"`
+}
+
+func newTaskInfo(taskTree *tasktree.TaskTree, t task.Task) (TaskInfo, error) {
+	info := TaskInfo{
+		Id:            t.Id,
+		Alias:         t.Alias,
+		Name:          t.Name,
+		Description:   t.Description,
+		Completed:     t.Completed,
+		Priority:      t.Priority.String(),
+		Tags:          util.Map(t.Tags, func(tag task.Tag) string { return string(tag) }),
+		EstimatedTime: t.EstimatedTime.String(),
+		TimeInvested:  t.TimeInvested.String(),
+		Due:           formatDate(t.Due),
+		Start:         formatDate(t.Start),
+		Overdue:       t.Overdue(time.Now()),
+	}
+	if !t.CompletedAt.IsZero() {
+		info.CompletedAt = t.CompletedAt.Format(time.RFC3339)
+	}
+	if !t.Recurrence.IsZero() {
+		info.Recurrence = t.Recurrence.String()
+	}
+
+	ancestors, err := taskTree.GetAncestorTasks(t.Id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	info.Depth = len(ancestors)
+	if len(ancestors) > 0 {
+		info.Parent = ancestors[0].Id
+	}
+
+	subtasks, err := taskTree.GetDirectSubtasksOf(t.Id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	info.Subtasks = util.Map(subtasks, func(t task.Task) task.Id { return t.Id })
+
+	blockers, err := taskTree.GetDirectBlockers(t.Id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	info.BlockedBy = util.Map(blockers, func(t task.Task) task.Id { return t.Id })
+
+	info.Pomodoros, err = taskTree.GetPomodoroCount(t.Id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	info.Blocked, err = taskTree.IsBlocked(t.Id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	rollup, err := taskTree.GetRollup(t.Id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	info.Rollup = RollupInfo{
+		EstimatedTime:     rollup.Estimate.String(),
+		TimeInvested:      rollup.Invested.String(),
+		RemainingTime:     rollup.Remaining.String(),
+		Tasks:             rollup.Tasks,
+		CompletedTasks:    rollup.CompletedTasks,
+		PercentByCount:    rollup.PercentByCount(),
+		PercentByEstimate: rollup.PercentByEstimate(),
+	}
+
+	return info, nil
+}
+
+type ShowCommand struct {
+}
+
+func (c ShowCommand) RunData(ctx *app.Context, args ...string) (any, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return newTaskInfo(ctx.TaskTree(), t)
+}
+
+func (c ShowCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	data, err := c.RunData(ctx, args...)
+	if err != nil {
+		return "", err.Error()
+	}
+	info := data.(TaskInfo)
+
+	status := "open"
+	if info.Completed {
+		status = "completed"
+	} else if info.Overdue {
+		status = "overdue"
+	} else if info.Blocked {
+		status = "blocked"
+	}
+
+	lines := []string{
+		info.Name,
+		fmt.Sprintf("id: %v (%v)", info.Alias, info.Id),
+		fmt.Sprintf("status: %v", status),
+		fmt.Sprintf("priority: %v", info.Priority),
+		fmt.Sprintf("estimated: %v, invested: %v", info.EstimatedTime, info.TimeInvested),
+	}
+	if info.Pomodoros > 0 {
+		lines = append(lines, fmt.Sprintf("pomodoros: %d", info.Pomodoros))
+	}
+	if info.Due != "" {
+		lines = append(lines, "due: "+info.Due)
+	}
+	if info.Start != "" {
+		lines = append(lines, "deferred until: "+info.Start)
+	}
+	if info.CompletedAt != "" {
+		lines = append(lines, "completed at: "+info.CompletedAt)
+	}
+	if info.Recurrence != "" {
+		lines = append(lines, "repeats: "+info.Recurrence)
+	}
+	if len(info.Tags) > 0 {
+		lines = append(lines, "tags: "+strings.Join(info.Tags, ", "))
+	}
+	if info.Parent != "" {
+		lines = append(lines, "parent: "+joinAliases(ctx.TaskTree(), []task.Id{info.Parent}))
+	}
+	if len(info.Subtasks) > 0 {
+		lines = append(lines, "subtasks: "+joinAliases(ctx.TaskTree(), info.Subtasks))
+		lines = append(lines, fmt.Sprintf("total estimated: %v, invested: %v, remaining: %v",
+			info.Rollup.EstimatedTime, info.Rollup.TimeInvested, info.Rollup.RemainingTime))
+		lines = append(lines, fmt.Sprintf("progress: %d/%d tasks (%.0f%%), %.0f%% by estimate",
+			info.Rollup.CompletedTasks, info.Rollup.Tasks, info.Rollup.PercentByCount, info.Rollup.PercentByEstimate))
+	}
+	if len(info.BlockedBy) > 0 {
+		lines = append(lines, "blocked by: "+joinAliases(ctx.TaskTree(), info.BlockedBy))
+	}
+	if info.Description != "" {
+		lines = append(lines, "description: "+info.Description)
+	}
+
+	return strings.Join(lines, "\n"), ""
+}
+
+func (c ShowCommand) Usage() string {
+	return "show <task>"
+}
+
+func (c ShowCommand) Name() string {
+	return "show"
+}
+
+// formatDate formats a date as e.g. "2026-11-03", or as an empty string if it is zero.
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(task.DateLayout)
+}
+
+// joinAliases lists tasks by alias and name.
+func joinAliases(taskTree *tasktree.TaskTree, ids []task.Id) string {
+	return strings.Join(util.Map(ids, func(id task.Id) string {
+		t, _ := taskTree.GetTask(id)
+		return fmt.Sprintf("%v (%v)", t.Alias, t.Name)
+	}), ", ")
+}
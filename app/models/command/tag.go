@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+)
+
+// parseTags parses tag arguments, allowing them to be prefixed with "#".
+func parseTags(args []string) ([]task.Tag, error) {
+	tags := make([]task.Tag, 0, len(args))
+	for _, arg := range args {
+		tag := strings.TrimPrefix(arg, "#")
+		if tag == "" || strings.ContainsFunc(tag, func(r rune) bool { return r == ' ' || r == '\t' }) {
+			return nil, fmt.Errorf("invalid tag %q", arg)
+		}
+		tags = append(tags, task.Tag(tag))
+	}
+	return tags, nil
+}
+
+type TagCommand struct {
+}
+
+func (c TagCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) < 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	tags, err := parseTags(args[2:])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		for _, tag := range tags {
+			if !util.Contains(t.Tags, tag) {
+				t.Tags = append(t.Tags, tag)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to tag task: %v", err)
+	}
+
+	return fmt.Sprintf("tagged task %v with %v", t.Alias, strings.Join(args[2:], ", ")), ""
+}
+
+func (c TagCommand) Usage() string {
+	return "tag <task> <tag>..."
+}
+
+func (c TagCommand) Name() string {
+	return "tag"
+}
+
+type UntagCommand struct {
+}
+
+func (c UntagCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) < 3 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	tags, err := parseTags(args[2:])
+	if err != nil {
+		return "", err.Error()
+	}
+
+	t, err := updateTask(ctx, args[1], func(t *task.Task) error {
+		for _, tag := range tags {
+			if !util.Contains(t.Tags, tag) {
+				return fmt.Errorf("task %v is not tagged with %v", t.Alias, tag)
+			}
+			t.Tags = util.Remove(t.Tags, tag)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Sprintf("failed to untag task: %v", err)
+	}
+
+	return fmt.Sprintf("removed tags %v from task %v", strings.Join(args[2:], ", "), t.Alias), ""
+}
+
+func (c UntagCommand) Usage() string {
+	return "untag <task> <tag>..."
+}
+
+func (c UntagCommand) Name() string {
+	return "untag"
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+)
+
+type StartCommand struct {
+}
+
+func (c StartCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	t, err := resolveTask(ctx, args[1])
+	if err != nil {
+		return "", fmt.Sprintf("failed to start timer: %v", err)
+	}
+
+	stopped, wasRunning, err := ctx.TaskTree().StartTimer(t.Id)
+	if err != nil {
+		return "", fmt.Sprintf("failed to start timer: %v", err)
+	}
+
+	started := fmt.Sprintf("started timer on task %v (%v)", t.Alias, t.Name)
+	if pomodoro := ctx.Pomodoro(); pomodoro != nil {
+		// The pomodoro would log the same time again.
+		ctx.SetPomodoro(nil)
+		started = describeStoppedPomodoro(ctx, pomodoro) + "; " + started
+	}
+	if wasRunning {
+		return describeStoppedTimer(ctx, stopped) + "; " + started, ""
+	}
+	return started, ""
+}
+
+func (c StartCommand) Usage() string {
+	return "start <task>"
+}
+
+func (c StartCommand) Name() string {
+	return "start"
+}
+
+type StopCommand struct {
+}
+
+func (c StopCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 1 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	stopped, err := ctx.TaskTree().StopTimer()
+	if errors.Is(err, tasktree.ErrNoActiveTimer) {
+		return "", err.Error()
+	} else if err != nil {
+		return "", fmt.Sprintf("failed to stop timer: %v", err)
+	}
+
+	return describeStoppedTimer(ctx, stopped), ""
+}
+
+func (c StopCommand) Usage() string {
+	return "stop"
+}
+
+func (c StopCommand) Name() string {
+	return "stop"
+}
+
+// describeStoppedTimer describes a session that was just stopped along with
+// the total time invested in its task.
+func describeStoppedTimer(ctx *app.Context, s tasktree.Session) string {
+	t, _ := ctx.TaskTree().GetTask(s.TaskId)
+	return fmt.Sprintf("stopped timer on task %v after %v (%v total)", t.Alias, s.Duration(s.End), t.TimeInvested)
+}
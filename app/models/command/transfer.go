@@ -0,0 +1,202 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// A format converts a whole TaskTree to and from a file format.
+type format struct {
+	marshal   func(*tasktree.TaskTree) ([]byte, error)
+	unmarshal func([]byte) (*tasktree.TaskTree, error)
+}
+
+// formats are the file formats supported by the export and import commands, keyed by name.
+var formats = map[string]format{
+	"json": {
+		marshal: func(tree *tasktree.TaskTree) ([]byte, error) {
+			return json.MarshalIndent(tree, "", "  ")
+		},
+		unmarshal: func(data []byte) (*tasktree.TaskTree, error) {
+			tree := tasktree.NewTaskTree()
+			if err := json.Unmarshal(data, tree); err != nil {
+				return nil, err
+			}
+			return tree, nil
+		},
+	},
+	"markdown": {
+		marshal: func(tree *tasktree.TaskTree) ([]byte, error) {
+			return tree.MarshalMarkdown()
+		},
+		unmarshal: func(data []byte) (*tasktree.TaskTree, error) {
+			tree := tasktree.NewTaskTree()
+			if err := tree.UnmarshalMarkdown(data); err != nil {
+				return nil, err
+			}
+			return tree, nil
+		},
+	},
+	"todotxt": {
+		marshal: func(tree *tasktree.TaskTree) ([]byte, error) {
+			return tree.MarshalTodoTxt()
+		},
+		unmarshal: func(data []byte) (*tasktree.TaskTree, error) {
+			tree := tasktree.NewTaskTree()
+			if err := tree.UnmarshalTodoTxt(data); err != nil {
+				return nil, err
+			}
+			return tree, nil
+		},
+	},
+}
+
+// parseFlag extracts a "--<flag> <value>" or "--<flag>=<value>" flag from the
+// arguments of a command, returning defaultValue if it is missing.
+func parseFlag(args []string, flag string, defaultValue string) (string, []string, error) {
+	value := defaultValue
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--"+flag:
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("missing value for --%v", flag)
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--"+flag+"="):
+			value = strings.TrimPrefix(args[i], "--"+flag+"=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return value, rest, nil
+}
+
+// parseFormatFlag extracts a "--format <name>" or "--format=<name>" flag from
+// the arguments of a command, defaulting to JSON.
+func parseFormatFlag(args []string) (format, []string, error) {
+	name, rest, err := parseFlag(args, "format", "json")
+	if err != nil {
+		return format{}, nil, err
+	}
+
+	f, exists := formats[name]
+	if !exists {
+		names := make([]string, 0, len(formats))
+		for name := range formats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return format{}, nil, fmt.Errorf("unknown format %q, expected one of: %v", name, strings.Join(names, ", "))
+	}
+	return f, rest, nil
+}
+
+type ExportCommand struct {
+}
+
+func (c ExportCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	f, args, err := parseFormatFlag(args)
+	if err != nil {
+		return "", err.Error()
+	}
+	if len(args) > 2 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	data, err := f.marshal(ctx.TaskTree())
+	if err != nil {
+		return "", fmt.Sprintf("failed to export task tree: %v", err)
+	}
+
+	// Without a file, the exported tree is the output of the command.
+	if len(args) == 1 || args[1] == "-" {
+		return strings.TrimSuffix(string(data), "\n"), ""
+	}
+
+	if err := os.WriteFile(args[1], data, 0o644); err != nil {
+		return "", fmt.Sprintf("failed to export task tree: %v", err)
+	}
+	return fmt.Sprintf("exported task tree to %v", args[1]), ""
+}
+
+func (c ExportCommand) Usage() string {
+	return "export [--format <format>] [<file>]"
+}
+
+func (c ExportCommand) Name() string {
+	return "export"
+}
+
+type ImportCommand struct {
+}
+
+// parseArgs extracts the format and the file to import from the arguments.
+func (c ImportCommand) parseArgs(ctx *app.Context, args []string) (format, string, error) {
+	f, args, err := parseFormatFlag(args)
+	if err != nil {
+		return format{}, "", err
+	}
+	if len(args) != 2 {
+		return format{}, "", fmt.Errorf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+	// The interactive interface reads the terminal itself.
+	if args[1] == "-" && ctx.Interactive() {
+		return format{}, "", fmt.Errorf("can't import from standard input in the interactive interface, give a file")
+	}
+	return f, args[1], nil
+}
+
+func (c ImportCommand) Confirmation(ctx *app.Context, args ...string) string {
+	_, file, err := c.parseArgs(ctx, args)
+	if err != nil {
+		// The error is reported when running the command.
+		return ""
+	}
+
+	n := len(ctx.TaskTree().GetAllTasks())
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("replace the %v in the task tree with %v (this can't be undone)?", pluralize(n, "task"), file)
+}
+
+func (c ImportCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	f, file, err := c.parseArgs(ctx, args)
+	if err != nil {
+		return "", err.Error()
+	}
+
+	var data []byte
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return "", fmt.Sprintf("failed to import task tree: %v", err)
+	}
+
+	imported, err := f.unmarshal(data)
+	if err != nil {
+		return "", fmt.Sprintf("failed to import task tree: %v", err)
+	}
+	ctx.SetTaskTree(imported)
+
+	return fmt.Sprintf("imported %d tasks, replacing the previous task tree", len(imported.GetAllTasks())), ""
+}
+
+func (c ImportCommand) Usage() string {
+	return "import [--format <format>] <file>"
+}
+
+func (c ImportCommand) Name() string {
+	return "import"
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+)
+
+type UndoCommand struct {
+}
+
+func (c UndoCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 1 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	name, err := ctx.TaskTree().Undo()
+	if err != nil {
+		return "", err.Error()
+	}
+
+	return fmt.Sprintf("undid %v", name), ""
+}
+
+func (c UndoCommand) Usage() string {
+	return "undo"
+}
+
+func (c UndoCommand) Name() string {
+	return "undo"
+}
+
+type RedoCommand struct {
+}
+
+func (c RedoCommand) Run(ctx *app.Context, args ...string) (string, string) {
+	if len(args) != 1 {
+		return "", fmt.Sprintf("incorrect number of arguments, usage: %v", c.Usage())
+	}
+
+	name, err := ctx.TaskTree().Redo()
+	if err != nil {
+		return "", err.Error()
+	}
+
+	return fmt.Sprintf("redid %v", name), ""
+}
+
+func (c RedoCommand) Usage() string {
+	return "redo"
+}
+
+func (c RedoCommand) Name() string {
+	return "redo"
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+)
+
+// resolveTask finds the task a reference typed by the user refers to. See
+// tasktree.TaskTree.Resolve for the accepted references.
+func resolveTask(ctx *app.Context, ref string) (task.Task, error) {
+	id, err := ctx.TaskTree().Resolve(ref)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	t, exists := ctx.TaskTree().GetTask(id)
+	if !exists {
+		return task.Task{}, fmt.Errorf("task %v does not exist", id)
+	}
+	return t, nil
+}
+
+// resolveTaskPair resolves the two task references taken by commands such as block.
+func resolveTaskPair(ctx *app.Context, firstRef string, secondRef string) (task.Task, task.Task, error) {
+	first, err := resolveTask(ctx, firstRef)
+	if err != nil {
+		return task.Task{}, task.Task{}, err
+	}
+	second, err := resolveTask(ctx, secondRef)
+	if err != nil {
+		return task.Task{}, task.Task{}, err
+	}
+	return first, second, nil
+}
+
+// updateTask applies an edit to a task in the context's TaskTree.
+func updateTask(ctx *app.Context, ref string, edit func(t *task.Task) error) (task.Task, error) {
+	t, err := resolveTask(ctx, ref)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	if err := edit(&t); err != nil {
+		return task.Task{}, err
+	}
+
+	return t, ctx.TaskTree().UpdateTask(t)
+}
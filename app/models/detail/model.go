@@ -0,0 +1,172 @@
+package detail
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Model shows every field of the selected task along with its blockers and ancestry.
+type Model struct {
+	ctx *app.Context
+
+	taskId   task.Id
+	selected bool
+
+	width  int
+	height int
+
+	titleStyle lipgloss.Style
+	labelStyle lipgloss.Style
+}
+
+func New(ctx *app.Context) Model {
+	return Model{
+		ctx:        ctx,
+		titleStyle: lipgloss.NewStyle().Bold(true),
+		labelStyle: lipgloss.NewStyle().Faint(true),
+	}
+}
+
+// SetTask sets the task to show. If selected is false, no task is shown.
+func (m *Model) SetTask(id task.Id, selected bool) {
+	m.taskId = id
+	m.selected = selected
+}
+
+// SetSize sets the dimensions available to the pane.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+func (m Model) View() string {
+	style := lipgloss.NewStyle()
+	if m.width > 0 {
+		style = style.Width(m.width)
+	}
+	if m.height > 0 {
+		style = style.Height(m.height).MaxHeight(m.height)
+	}
+
+	if !m.selected {
+		return style.Render(m.labelStyle.Render("No task selected."))
+	}
+
+	taskTree := m.ctx.TaskTree()
+	t, exists := taskTree.GetTask(m.taskId)
+	if !exists {
+		return style.Render(m.labelStyle.Render("No task selected."))
+	}
+
+	lines := []string{m.titleStyle.Render(t.Name), ""}
+	field := func(label string, value string) {
+		lines = append(lines, m.labelStyle.Render(label+": ")+value)
+	}
+
+	field("Alias", t.Alias)
+	field("ID", string(t.Id))
+	now := time.Now()
+	if t.Completed {
+		field("Status", "completed")
+	} else if t.Overdue(now) {
+		field("Status", "overdue")
+	} else {
+		field("Status", "open")
+	}
+	field("Priority", t.Priority.String())
+	field("Estimated", t.EstimatedTime.String())
+	field("Invested", t.TimeInvested.String())
+	if pomodoros, _ := taskTree.GetPomodoroCount(t.Id); pomodoros > 0 {
+		field("Pomodoros", strconv.Itoa(pomodoros))
+	}
+	field("Due", orNone(formatDate(t.Due, now)))
+	if !t.Start.IsZero() {
+		field("Deferred until", formatDate(t.Start, now))
+	}
+	if !t.Recurrence.IsZero() {
+		field("Repeats", t.Recurrence.String())
+	}
+	if !t.CompletedAt.IsZero() {
+		field("Completed at", t.CompletedAt.Format("2006-01-02 15:04"))
+	}
+	field("Tags", orNone(strings.Join(util.Map(t.Tags, func(tag task.Tag) string { return "#" + string(tag) }), " ")))
+
+	subtasks, _ := taskTree.GetDirectSubtasksOf(t.Id)
+	completed := util.Filter(subtasks, func(t task.Task) bool { return t.Completed })
+	field("Subtasks", fmt.Sprintf("%d/%d complete", len(completed), len(subtasks)))
+	if rollup, err := taskTree.GetRollup(t.Id); err == nil && len(subtasks) > 0 {
+		field("Total estimated", rollup.Estimate.String())
+		field("Total invested", rollup.Invested.String())
+		field("Remaining", rollup.Remaining.String())
+		progress := fmt.Sprintf("%d/%d tasks (%.0f%%)", rollup.CompletedTasks, rollup.Tasks, rollup.PercentByCount())
+		if rollup.Estimate > 0 {
+			progress += fmt.Sprintf(", %.0f%% by estimate", rollup.PercentByEstimate())
+		}
+		field("Progress", progress)
+	}
+
+	directBlockers, _ := taskTree.GetDirectBlockers(t.Id)
+	allBlockers, _ := taskTree.GetAllBlockers(t.Id)
+	directBlockerIds := util.Map(directBlockers, func(blocker task.Task) task.Id { return blocker.Id })
+	inheritedBlockers := util.Filter(allBlockers, func(blocker task.Task) bool {
+		return !util.Contains(directBlockerIds, blocker.Id)
+	})
+	field("Blocked by", orNone(joinNames(directBlockers)))
+	field("Inherited blockers", orNone(joinNames(inheritedBlockers)))
+
+	ancestors, _ := taskTree.GetAncestorTasks(t.Id)
+	path := make([]task.Task, 0, len(ancestors))
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		path = append(path, ancestors[i])
+	}
+	if len(path) == 0 {
+		field("Ancestors", "none (root task)")
+	} else {
+		field("Ancestors", strings.Join(util.Map(path, func(t task.Task) string { return t.Name }), " › "))
+	}
+
+	if t.Description != "" {
+		lines = append(lines, "", t.Description)
+	}
+
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+func joinNames(tasks []task.Task) string {
+	return strings.Join(util.Map(tasks, func(t task.Task) string { return t.Name }), ", ")
+}
+
+// formatDate formats a date relative to now, e.g. "tomorrow (2026-10-19)", or
+// as an empty string if it is zero.
+func formatDate(date time.Time, now time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	relative := task.FormatDate(date, now)
+	if absolute := date.Format(task.DateLayout); relative != absolute {
+		return fmt.Sprintf("%v (%v)", relative, absolute)
+	}
+	return relative
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -1,12 +1,19 @@
 package models
 
 import (
+	"fmt"
 	"github.com/carreter/tasktree-go/app"
 	"github.com/carreter/tasktree-go/app/models/command"
+	"github.com/carreter/tasktree-go/app/models/detail"
+	"github.com/carreter/tasktree-go/app/models/next"
+	"github.com/carreter/tasktree-go/app/models/report"
 	"github.com/carreter/tasktree-go/app/models/tree"
-	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
 )
 
 type focus int
@@ -25,72 +32,331 @@ type Model struct {
 	treeView      tree.Model
 	treeViewStyle lipgloss.Style
 
+	// nextView replaces the tree view while showNext is set.
+	nextView next.Model
+	showNext bool
+
+	// reportView replaces the tree and next actions views while the context
+	// has a report.
+	reportView report.Model
+
+	detailView      detail.Model
+	detailViewStyle lipgloss.Style
+
+	timerStyle         lipgloss.Style
+	pomodoroWorkStyle  lipgloss.Style
+	pomodoroBreakStyle lipgloss.Style
+
 	width  int
 	height int
 
 	focus focus
+
+	// ringing is set from a BellMsg until the next TickMsg.
+	ringing bool
 }
 
-func NewModel(taskTree *tasktree.TaskTree) Model {
-	ctx := app.NewContext(taskTree)
+func NewModel(ctx *app.Context) Model {
 	return Model{
 		ctx:         ctx,
 		commandView: command.New(ctx),
-		treeView:    tree.NewModel(taskTree),
-		focus:       treeViewFocus,
+		treeView:    tree.NewModel(ctx),
+		nextView:    next.NewModel(ctx),
+		reportView:  report.NewModel(ctx),
+		detailView:  detail.New(ctx),
+		detailViewStyle: lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			PaddingLeft(1),
+		timerStyle: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2")),
+		pomodoroWorkStyle: lipgloss.NewStyle().Bold(true).Padding(0, 1).
+			Foreground(lipgloss.Color("15")).Background(lipgloss.Color("1")),
+		pomodoroBreakStyle: lipgloss.NewStyle().Bold(true).Padding(0, 1).
+			Foreground(lipgloss.Color("0")).Background(lipgloss.Color("2")),
+		focus: treeViewFocus,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.EnterAltScreen
+	return tea.Batch(tea.EnterAltScreen, app.Tick())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Global keybindings only apply to keys that weren't typed into the command bar.
+	treeFocused := m.focus == treeViewFocus
+
 	var focusedCmd tea.Cmd
 	switch m.focus {
 	case treeViewFocus:
+		if m.ctx.Report() != nil {
+			var newReportView tea.Model
+			newReportView, focusedCmd = m.reportView.Update(msg)
+			m.reportView = newReportView.(report.Model)
+			break
+		}
+		if m.showNext {
+			var newNextView tea.Model
+			newNextView, focusedCmd = m.nextView.Update(msg)
+			m.nextView = newNextView.(next.Model)
+			break
+		}
+
 		var newTreeView tea.Model
 		newTreeView, focusedCmd = m.treeView.Update(msg)
 		m.treeView = newTreeView.(tree.Model)
 	case commandFocus:
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			switch msg.String() {
-			case "esc", "enter":
-				m.focus = treeViewFocus
-			}
-		}
-
 		var newCommandView tea.Model
 		newCommandView, focusedCmd = m.commandView.Update(msg)
 		m.commandView = newCommandView.(command.Model)
+		// The command bar gives up focus once a command is run or cancelled,
+		// which may take a confirmation.
+		if !m.commandView.Focused() {
+			m.focus = treeViewFocus
+		}
 	}
 
 	var globalCmd tea.Cmd
 	switch msg := msg.(type) {
+	case app.StatusMsg:
+		m.commandView.SetStatus(msg.Output, msg.Err)
+	case app.BellMsg:
+		m.ringing = true
+	case app.TickMsg:
+		m.ringing = false
+		globalCmd = tea.Batch(app.Tick(), m.advancePomodoro())
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
 			globalCmd = tea.Quit
 		case "q":
-			if m.focus != commandFocus {
+			if treeFocused {
 				globalCmd = tea.Quit
 			}
 		case ":":
-			if m.focus != commandFocus {
+			if treeFocused {
 				m.focus = commandFocus
 				m.commandView.Focus()
 			}
+		case "/":
+			if treeFocused {
+				m.prompt("filter ")
+			}
+		case "n":
+			if treeFocused {
+				m.showNext = !m.showNext
+			}
+		case "enter":
+			// Show the selected next action in the tree.
+			if selected, ok := m.nextView.Selected(); ok && treeFocused && m.showNext {
+				m.showNext = false
+				m.treeView.Reveal(selected)
+			}
+		case "m":
+			if selected, ok := m.selected(); ok && treeFocused {
+				t, _ := m.ctx.TaskTree().GetTask(selected)
+				m.prompt(fmt.Sprintf("move %v ", t.Alias))
+			}
+		case "d":
+			if selected, ok := m.selected(); ok && treeFocused {
+				t, _ := m.ctx.TaskTree().GetTask(selected)
+				m.prompt(fmt.Sprintf("delete %v ", t.Alias))
+			}
+		case "t":
+			if selected, ok := m.selected(); ok && treeFocused {
+				globalCmd = m.toggleTimer(selected)
+			}
+		case "p":
+			if selected, ok := m.selected(); ok && treeFocused {
+				globalCmd = m.togglePomodoro(selected)
+			}
+		case "s":
+			if selected, ok := m.selected(); ok && treeFocused {
+				// Sort the siblings of the selected task.
+				parentRef := "root"
+				if parent, exists, _ := m.ctx.TaskTree().GetParentTask(selected); exists {
+					parentRef = parent.Alias
+				}
+				m.prompt(fmt.Sprintf("sort %v ", parentRef))
+			}
 		}
 	}
 
+	m.detailView.SetTask(m.selected())
+
+	if err := m.ctx.Sync(); err != nil {
+		m.commandView.SetError(fmt.Sprintf("could not save task tree: %v", err))
+	}
+
 	return m, tea.Batch(focusedCmd, globalCmd)
 }
 
 func (m Model) View() string {
-	return lipgloss.JoinVertical(
+	bell := ""
+	if m.ringing {
+		// The renderer only rewrites lines that changed, so the bell rings
+		// once when it is added to the first line.
+		bell = "\a"
+	}
+	return bell + lipgloss.JoinVertical(
 		lipgloss.Left,
-		m.treeViewStyle.Render(m.treeView.View()),
-		m.commandViewStyle.Render(m.commandView.View()),
+		lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			m.treeViewStyle.Render(m.mainView()),
+			m.detailViewStyle.Render(m.detailView.View()),
+		),
+		m.statusLine(),
 	)
 }
+
+// statusLine renders the command bar, followed on the right by the running
+// timer and pomodoro if there are any.
+func (m Model) statusLine() string {
+	status := m.commandViewStyle.Render(m.commandView.View())
+	timer := strings.Join(util.Filter([]string{m.pomodoroView(), m.timerView()}, func(s string) bool { return s != "" }), " ")
+	if timer == "" || m.width == 0 {
+		return status
+	}
+
+	width := max(m.width-lipgloss.Width(timer)-1, 0)
+	status = lipgloss.NewStyle().MaxWidth(width).Render(status)
+	return status + strings.Repeat(" ", max(width-lipgloss.Width(status), 0)+1) + timer
+}
+
+// timerView renders the task the timer is running for and the elapsed time,
+// or an empty string if no timer is running.
+func (m Model) timerView() string {
+	session, running := m.ctx.TaskTree().ActiveTimer()
+	if !running {
+		return ""
+	}
+	t, _ := m.ctx.TaskTree().GetTask(session.TaskId)
+	elapsed := session.Duration(time.Now()).Truncate(time.Second)
+	return m.timerStyle.Render(fmt.Sprintf("⏱ %v %d:%02d:%02d", t.Alias, int(elapsed.Hours()), int(elapsed.Minutes())%60, int(elapsed.Seconds())%60))
+}
+
+// pomodoroView renders the countdown of the current pomodoro interval, or an
+// empty string if no pomodoro is running.
+func (m Model) pomodoroView() string {
+	pomodoro := m.ctx.Pomodoro()
+	if pomodoro == nil {
+		return ""
+	}
+	t, _ := m.ctx.TaskTree().GetTask(pomodoro.TaskId)
+	remaining := pomodoro.Remaining(time.Now()).Round(time.Second)
+	countdown := fmt.Sprintf("🍅 %v %v %02d:%02d", pomodoro.Phase, t.Alias, int(remaining.Minutes()), int(remaining.Seconds())%60)
+	if every := pomodoro.Settings.LongBreakEvery; every > 0 {
+		// Show the progress towards the next long break as filled dots.
+		done := pomodoro.Completed % every
+		if pomodoro.Phase == app.LongBreakPhase {
+			done = every
+		}
+		countdown += " " + strings.Repeat("●", done) + strings.Repeat("○", every-done)
+	}
+	if pomodoro.Phase == app.WorkPhase {
+		return m.pomodoroWorkStyle.Render(countdown)
+	}
+	return m.pomodoroBreakStyle.Render(countdown)
+}
+
+// advancePomodoro moves the running pomodoro on to the next interval if the
+// current one has ended, crediting a finished work interval to the time log of
+// its task, and rings the bell.
+func (m Model) advancePomodoro() tea.Cmd {
+	pomodoro := m.ctx.Pomodoro()
+	if pomodoro == nil {
+		return nil
+	}
+	work, advanced := pomodoro.Advance(time.Now())
+	if !advanced {
+		return nil
+	}
+
+	var logged time.Duration
+	if work.TaskId != "" {
+		if err := m.ctx.TaskTree().LogPomodoro(work.TaskId, work.Start, work.End); err != nil {
+			// The task was deleted in the meantime.
+			m.ctx.SetPomodoro(nil)
+			return tea.Batch(app.Bell(), func() tea.Msg {
+				return app.StatusMsg{Err: fmt.Sprintf("stopped pomodoro: failed to log time: %v", err)}
+			})
+		}
+		logged = work.Duration(work.End)
+	}
+	out := command.DescribePomodoroAdvance(m.ctx, pomodoro, logged)
+	return tea.Batch(app.Bell(), func() tea.Msg {
+		return app.StatusMsg{Output: out}
+	})
+}
+
+// togglePomodoro stops the pomodoro if it is running for a task, and starts
+// one for the task otherwise, reporting the outcome in the command bar.
+func (m Model) togglePomodoro(id task.Id) tea.Cmd {
+	t, _ := m.ctx.TaskTree().GetTask(id)
+	var out, errMsg string
+	if pomodoro := m.ctx.Pomodoro(); pomodoro != nil && pomodoro.TaskId == id {
+		out, errMsg = command.PomodoroCommand{}.Run(m.ctx, "pomodoro", "stop")
+	} else {
+		out, errMsg = command.PomodoroCommand{}.Run(m.ctx, "pomodoro", t.Alias)
+	}
+	return func() tea.Msg {
+		return app.StatusMsg{Output: out, Err: errMsg}
+	}
+}
+
+// toggleTimer stops the timer if it is running for a task, and starts it for
+// the task otherwise, reporting the outcome in the command bar.
+func (m Model) toggleTimer(id task.Id) tea.Cmd {
+	t, _ := m.ctx.TaskTree().GetTask(id)
+	var out, errMsg string
+	if session, running := m.ctx.TaskTree().ActiveTimer(); running && session.TaskId == id {
+		out, errMsg = command.StopCommand{}.Run(m.ctx, "stop")
+	} else {
+		out, errMsg = command.StartCommand{}.Run(m.ctx, "start", t.Alias)
+	}
+	return func() tea.Msg {
+		return app.StatusMsg{Output: out, Err: errMsg}
+	}
+}
+
+// selected returns the task selected in the tree view, or in the next actions
+// view when it is shown.
+func (m Model) selected() (task.Id, bool) {
+	if m.showNext {
+		return m.nextView.Selected()
+	}
+	return m.treeView.Selected()
+}
+
+// mainView renders the tree view, or the next actions or report view when it
+// is shown.
+func (m Model) mainView() string {
+	if m.ctx.Report() != nil {
+		return m.reportView.View()
+	}
+	if m.showNext {
+		return m.nextView.View()
+	}
+	return m.treeView.View()
+}
+
+// prompt enters command mode with the command bar prefilled with a value.
+func (m *Model) prompt(value string) {
+	m.focus = commandFocus
+	m.commandView.Focus()
+	m.commandView.SetValue(value)
+}
+
+// resize lays out the tree view and detail pane side by side above the command bar.
+func (m *Model) resize() {
+	bodyHeight := max(m.height-lipgloss.Height(m.commandView.View()), 1)
+	detailWidth := min(max(m.width/3, 30), 60)
+	treeWidth := max(m.width-detailWidth-m.detailViewStyle.GetHorizontalFrameSize(), 1)
+
+	m.treeViewStyle = m.treeViewStyle.Width(treeWidth).MaxWidth(treeWidth).Height(bodyHeight).MaxHeight(bodyHeight)
+	m.treeView.SetSize(treeWidth, bodyHeight)
+	m.nextView.SetSize(treeWidth, bodyHeight)
+	m.reportView.SetSize(treeWidth, bodyHeight)
+	m.detailView.SetSize(detailWidth, bodyHeight)
+}
@@ -0,0 +1,184 @@
+// Package next implements the next actions view, listing the tasks that can
+// be worked on right now.
+package next
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/app/models/command"
+	"github.com/carreter/tasktree-go/pkg/task"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+)
+
+// Model lists the next actions of the TaskTree, see tasktree.TaskTree.GetNextActions.
+type Model struct {
+	ctx *app.Context
+
+	cursor task.Id
+	offset int // index of the first visible task
+
+	width  int
+	height int
+
+	titleStyle    lipgloss.Style
+	selectedStyle lipgloss.Style
+	contextStyle  lipgloss.Style
+}
+
+func NewModel(ctx *app.Context) Model {
+	return Model{
+		ctx:           ctx,
+		titleStyle:    lipgloss.NewStyle().Bold(true),
+		selectedStyle: lipgloss.NewStyle().Reverse(true),
+		contextStyle:  lipgloss.NewStyle().Faint(true),
+	}
+}
+
+// SetSize sets the dimensions available to the view.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.scrollToCursor(m.ctx.TaskTree().GetNextActions())
+}
+
+// Selected returns the task under the cursor, if any.
+func (m Model) Selected() (task.Id, bool) {
+	next := m.ctx.TaskTree().GetNextActions()
+	if len(next) == 0 {
+		return "", false
+	}
+	return next[m.cursorIndex(next)].Id, true
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	next := m.ctx.TaskTree().GetNextActions()
+	if len(next) == 0 {
+		return m, nil
+	}
+	i := m.cursorIndex(next)
+
+	var cmd tea.Cmd
+	switch keyMsg.String() {
+	case "j", "down":
+		i = min(i+1, len(next)-1)
+	case "k", "up":
+		i = max(i-1, 0)
+	case "g", "home":
+		i = 0
+	case "G", "end":
+		i = len(next) - 1
+	case "x":
+		cmd = m.complete(next[i])
+		// Keep the cursor in place once the task leaves the list.
+		if i+1 < len(next) {
+			i++
+		} else {
+			i = max(i-1, 0)
+		}
+	}
+	m.cursor = next[i].Id
+
+	m.scrollToCursor(next)
+	return m, cmd
+}
+
+// complete completes a task and reports which tasks changed.
+func (m Model) complete(t task.Task) tea.Cmd {
+	changed, created, err := m.ctx.TaskTree().CompleteTask(t.Id)
+	return func() tea.Msg {
+		if err != nil {
+			return app.StatusMsg{Err: fmt.Sprintf("task %v: %v", t.Alias, err)}
+		}
+		return app.StatusMsg{Output: command.DescribeCompletion(t, changed, created)}
+	}
+}
+
+func (m Model) View() string {
+	next := m.ctx.TaskTree().GetNextActions()
+	lines := []string{m.titleStyle.Render("Next actions")}
+	if len(next) == 0 {
+		lines = append(lines, "Nothing to do right now.")
+	}
+
+	cursor := m.cursorIndex(next)
+	end := len(next)
+	if height := m.listHeight(); height > 0 {
+		end = min(m.offset+height, len(next))
+	}
+	for i := min(m.offset, len(next)); i < end; i++ {
+		t := next[i]
+		line := fmt.Sprintf("%d. %v %v", i+1, t.Alias, t.Name)
+		if t.Priority != task.Default {
+			line += fmt.Sprintf(" [%v]", t.Priority)
+		}
+		if i == cursor {
+			line = m.selectedStyle.Render(line)
+		}
+		if context := m.context(t.Id); context != "" {
+			line += " " + m.contextStyle.Render(context)
+		}
+		lines = append(lines, line)
+	}
+
+	view := strings.Join(lines, "\n")
+	if m.width > 0 {
+		// Truncate rather than wrap long lines so that every task stays on one line.
+		view = lipgloss.NewStyle().MaxWidth(m.width).Render(view)
+	}
+	return view
+}
+
+// context renders the path to the parent of a task.
+func (m Model) context(id task.Id) string {
+	ancestors, _ := m.ctx.TaskTree().GetAncestorTasks(id)
+	names := make([]string, len(ancestors))
+	for i, ancestor := range ancestors {
+		names[len(ancestors)-1-i] = ancestor.Name
+	}
+	return strings.Join(names, " › ")
+}
+
+// cursorIndex returns the index of the cursor in next, or 0 if the cursor
+// task is no longer a next action.
+func (m Model) cursorIndex(next []task.Task) int {
+	for i, t := range next {
+		if t.Id == m.cursor {
+			return i
+		}
+	}
+	return 0
+}
+
+// listHeight returns the number of lines available to the list, or 0 if unknown.
+func (m Model) listHeight() int {
+	if m.height == 0 {
+		return 0
+	}
+	return max(m.height-1, 1)
+}
+
+// scrollToCursor adjusts the scroll offset so that the cursor is visible.
+func (m *Model) scrollToCursor(next []task.Task) {
+	height := m.listHeight()
+	if height == 0 {
+		return
+	}
+
+	i := m.cursorIndex(next)
+	if i < m.offset {
+		m.offset = i
+	} else if i >= m.offset+height {
+		m.offset = i - height + 1
+	}
+}
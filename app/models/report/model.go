@@ -0,0 +1,118 @@
+// Package report implements the report view, showing the time report last
+// created by the report command as a table.
+package report
+
+import (
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/app/models/command"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+)
+
+// Model shows the report of the app.Context, recomputed over the same range
+// whenever it is rendered so that the running timer stays up to date.
+type Model struct {
+	ctx *app.Context
+
+	offset int // index of the first visible line
+
+	width  int
+	height int
+
+	titleStyle lipgloss.Style
+	hintStyle  lipgloss.Style
+}
+
+func NewModel(ctx *app.Context) Model {
+	return Model{
+		ctx:        ctx,
+		titleStyle: lipgloss.NewStyle().Bold(true),
+		hintStyle:  lipgloss.NewStyle().Faint(true),
+	}
+}
+
+// SetSize sets the dimensions available to the view.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	lastOffset := max(len(m.lines())-m.bodyHeight(), 0)
+	switch keyMsg.String() {
+	case "j", "down":
+		m.offset = min(m.offset+1, lastOffset)
+	case "k", "up":
+		m.offset = max(m.offset-1, 0)
+	case "g", "home":
+		m.offset = 0
+	case "G", "end":
+		m.offset = lastOffset
+	case "esc":
+		m.ctx.SetReport(nil)
+		m.offset = 0
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	lines := m.lines()
+	start := min(m.offset, len(lines))
+	end := len(lines)
+	if height := m.bodyHeight(); height > 0 {
+		end = min(start+height, len(lines))
+	}
+
+	view := strings.Join(append([]string{m.title()}, lines[start:end]...), "\n")
+	if m.width > 0 {
+		view = lipgloss.NewStyle().MaxWidth(m.width).Render(view)
+	}
+	return view
+}
+
+// title renders the summary of the report along with how to close it.
+func (m Model) title() string {
+	report := m.ctx.Report()
+	if report == nil {
+		return ""
+	}
+	current, err := m.ctx.TaskTree().GetReport(report.Grouping, report.From, report.To)
+	if err != nil {
+		return ""
+	}
+	return m.titleStyle.Render(command.DescribeReport(current)) + " " + m.hintStyle.Render("(esc to close)")
+}
+
+// lines renders the table of the report, one line per element.
+func (m Model) lines() []string {
+	report := m.ctx.Report()
+	if report == nil {
+		return nil
+	}
+	current, err := m.ctx.TaskTree().GetReport(report.Grouping, report.From, report.To)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if len(current.Rows) == 0 {
+		return []string{"No time logged."}
+	}
+	return strings.Split(command.RenderReportTable(current), "\n")
+}
+
+// bodyHeight returns the number of lines available below the title, or 0 if unknown.
+func (m Model) bodyHeight() int {
+	if m.height == 0 {
+		return 0
+	}
+	return max(m.height-1, 1)
+}
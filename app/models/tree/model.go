@@ -2,29 +2,109 @@ package tree
 
 import (
 	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/app/models/command"
+	"github.com/carreter/tasktree-go/pkg/query"
+	"github.com/carreter/tasktree-go/pkg/task"
 	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/util"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/google/uuid"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"time"
 )
 
+// dueSoonDays is how many days ahead of their due date open tasks are
+// highlighted, today being 0.
+const dueSoonDays = 2
+
 type Model struct {
-	taskTree *tasktree.TaskTree
-	root     *uuid.UUID
+	ctx *app.Context
+
+	root      *task.Id // task the view is zoomed into, nil shows every root task
+	cursor    task.Id
+	collapsed map[task.Id]bool
+	offset    int // index of the first visible line
+
+	width  int
+	height int
+
+	cache *viewCache // shared by copies of the model, see derived
+
+	breadcrumbStyle lipgloss.Style
+	itemStyle       lipgloss.Style
+	selectedStyle   lipgloss.Style
+	blockedStyle    lipgloss.Style
+	contextStyle    lipgloss.Style
+	progressStyle   lipgloss.Style
+	criticalStyle   lipgloss.Style
+	dueStyle        lipgloss.Style
+	dueSoonStyle    lipgloss.Style
+	overdueStyle    lipgloss.Style
 }
 
-func NewModel(taskTree *tasktree.TaskTree) Model {
+func NewModel(ctx *app.Context) Model {
 	return Model{
-		taskTree: taskTree,
-		root:     nil,
+		ctx:             ctx,
+		root:            nil,
+		collapsed:       make(map[task.Id]bool),
+		cache:           &viewCache{},
+		breadcrumbStyle: lipgloss.NewStyle().Faint(true),
+		selectedStyle:   lipgloss.NewStyle().Reverse(true),
+		blockedStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		contextStyle:    lipgloss.NewStyle().Faint(true),
+		progressStyle:   lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		criticalStyle:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3")),
+		dueStyle:        lipgloss.NewStyle().Faint(true),
+		dueSoonStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+		overdueStyle:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")),
 	}
 }
 
-func (m Model) Root(id uuid.UUID) {
+// Root zooms the view into the subtree rooted at a given task.
+func (m *Model) Root(id task.Id) {
 	m.root = &id
+	m.cursor = id
+	m.offset = 0
 }
 
-func (m Model) ClearRoot() {
+// ClearRoot zooms the view back out to show every root task.
+func (m *Model) ClearRoot() {
+	if m.root != nil {
+		m.cursor = *m.root
+	}
 	m.root = nil
+	m.offset = 0
+}
+
+// Reveal moves the cursor to a task, expanding its ancestors and zooming out
+// if the task is outside of the zoomed in subtree.
+func (m *Model) Reveal(id task.Id) {
+	ancestors, _ := m.ctx.TaskTree().GetAncestorTasks(id)
+	if m.root != nil && *m.root != id && !util.Contains(util.Map(ancestors, func(t task.Task) task.Id { return t.Id }), *m.root) {
+		m.ClearRoot()
+	}
+	for _, ancestor := range ancestors {
+		delete(m.collapsed, ancestor.Id)
+	}
+	m.cursor = id
+	m.scrollToCursor()
+}
+
+// SetSize sets the dimensions available to the view.
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.scrollToCursor()
+}
+
+// Selected returns the task under the cursor, if any.
+func (m Model) Selected() (task.Id, bool) {
+	visible := m.visibleIds()
+	if len(visible) == 0 {
+		return "", false
+	}
+	return visible[m.cursorIndex(visible)], true
 }
 
 func (m Model) Init() tea.Cmd {
@@ -32,14 +112,380 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	return m, nil
+	if m.root != nil {
+		if _, exists := m.ctx.TaskTree().GetTask(*m.root); !exists {
+			m.root = nil
+		}
+	}
+
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		visible := m.visibleIds()
+		if len(visible) == 0 {
+			return m, nil
+		}
+		i := m.cursorIndex(visible)
+		m.cursor = visible[i]
+
+		switch msg.String() {
+		case "j", "down":
+			m.cursor = visible[min(i+1, len(visible)-1)]
+		case "k", "up":
+			m.cursor = visible[max(i-1, 0)]
+		case "g", "home":
+			m.cursor = visible[0]
+		case "G", "end":
+			m.cursor = visible[len(visible)-1]
+		case "h", "left":
+			if m.hasSubtasks(m.cursor) && !m.collapsed[m.cursor] {
+				m.collapsed[m.cursor] = true
+			} else if parent, exists, _ := m.ctx.TaskTree().GetParentTask(m.cursor); exists && util.Contains(visible, parent.Id) {
+				m.cursor = parent.Id
+			}
+		case "l", "right":
+			if m.collapsed[m.cursor] {
+				delete(m.collapsed, m.cursor)
+			} else if subtasks, _ := m.ctx.TaskTree().GetDirectSubtasksOf(m.cursor); len(subtasks) > 0 {
+				m.cursor = subtasks[0].Id
+			}
+		case " ":
+			if m.collapsed[m.cursor] {
+				delete(m.collapsed, m.cursor)
+			} else if m.hasSubtasks(m.cursor) {
+				m.collapsed[m.cursor] = true
+			}
+		case "enter":
+			delete(m.collapsed, m.cursor)
+			m.Root(m.cursor)
+		case "backspace":
+			if m.root == nil {
+				break
+			}
+			if parent, exists, _ := m.ctx.TaskTree().GetParentTask(*m.root); exists {
+				cursor := *m.root
+				m.Root(parent.Id)
+				m.cursor = cursor
+			} else {
+				m.ClearRoot()
+			}
+		case "esc":
+			m.ClearRoot()
+		case "x":
+			cmd = m.toggleCompleted()
+		case "tab":
+			cmd = m.moveAndReport(m.ctx.TaskTree().IndentTask, "indented")
+		case "shift+tab":
+			cmd = m.moveAndReport(m.ctx.TaskTree().OutdentTask, "outdented")
+		case "K":
+			cmd = m.moveAndReport(m.ctx.TaskTree().MoveUp, "moved up")
+		case "J":
+			cmd = m.moveAndReport(m.ctx.TaskTree().MoveDown, "moved down")
+		case "[":
+			cmd = m.moveAndReport(m.ctx.TaskTree().MoveToTop, "moved to the top")
+		case "]":
+			cmd = m.moveAndReport(m.ctx.TaskTree().MoveToBottom, "moved to the bottom")
+		case "u":
+			cmd = m.runAndReport(m.ctx.TaskTree().Undo, "undid")
+		case "ctrl+r":
+			cmd = m.runAndReport(m.ctx.TaskTree().Redo, "redid")
+		}
+	}
+
+	m.scrollToCursor()
+	return m, cmd
+}
+
+// runAndReport runs an undo or redo function and reports its outcome in the command bar.
+func (m Model) runAndReport(f func() (string, error), verb string) tea.Cmd {
+	name, err := f()
+	return func() tea.Msg {
+		if err != nil {
+			return app.StatusMsg{Err: err.Error()}
+		}
+		return app.StatusMsg{Output: fmt.Sprintf("%v %v", verb, name)}
+	}
+}
+
+// toggleCompleted completes or reopens the selected task and reports which
+// tasks changed, since the completion policy may change others too.
+func (m Model) toggleCompleted() tea.Cmd {
+	t, _ := m.ctx.TaskTree().GetTask(m.cursor)
+	var changed, created []task.Task
+	var err error
+	if t.Completed {
+		changed, err = m.ctx.TaskTree().ReopenTask(t.Id)
+	} else {
+		changed, created, err = m.ctx.TaskTree().CompleteTask(t.Id)
+	}
+	return func() tea.Msg {
+		if err != nil {
+			return app.StatusMsg{Err: fmt.Sprintf("task %v: %v", t.Alias, err)}
+		}
+		return app.StatusMsg{Output: command.DescribeCompletion(t, changed, created)}
+	}
+}
+
+// moveAndReport moves the selected task and reports the outcome in the command
+// bar. The new parent of the task is expanded so that it stays visible.
+func (m Model) moveAndReport(move func(id task.Id) error, verb string) tea.Cmd {
+	t, _ := m.ctx.TaskTree().GetTask(m.cursor)
+	err := move(m.cursor)
+	if parent, exists, _ := m.ctx.TaskTree().GetParentTask(m.cursor); err == nil && exists {
+		delete(m.collapsed, parent.Id)
+	}
+	return func() tea.Msg {
+		if err != nil {
+			return app.StatusMsg{Err: err.Error()}
+		}
+		return app.StatusMsg{Output: fmt.Sprintf("task %v %v", t.Alias, verb)}
+	}
 }
 
 func (m Model) View() string {
-	tree, err := RenderTaskTree(m.taskTree, 4)
+	derived := m.derived()
+	opts := RenderOptions{
+		Depth:             -1,
+		Collapsed:         m.collapsed,
+		Shown:             derived.shown,
+		Matched:           derived.matched,
+		Critical:          derived.critical,
+		DirectlyBlocked:   derived.directlyBlocked,
+		BlockedByAncestor: derived.blockedByAncestor,
+		ItemStyle:         m.itemStyle,
+		SelectedStyle:     m.selectedStyle,
+		BlockedStyle:      m.blockedStyle,
+		ContextStyle:      m.contextStyle,
+		ProgressWidth:     10,
+		ProgressStyle:     m.progressStyle,
+		CriticalStyle:     m.criticalStyle,
+		Now:               time.Now(),
+		DueSoonDays:       dueSoonDays,
+		DueStyle:          m.dueStyle,
+		DueSoonStyle:      m.dueSoonStyle,
+		OverdueStyle:      m.overdueStyle,
+	}
+	if selected, ok := m.Selected(); ok {
+		opts.Selected = selected
+	}
+
+	var rendered string
+	var err error
+	if m.root != nil {
+		rendered, err = RenderTaskTreeFromRoot(m.ctx.TaskTree(), *m.root, opts)
+	} else {
+		rendered, err = RenderTaskTree(m.ctx.TaskTree(), opts)
+	}
 	if err != nil {
 		return fmt.Sprintf("could not render tree: %v", err)
 	}
 
-	return tree
+	header := m.header()
+	var lines []string
+	if rendered != "" {
+		lines = strings.Split(rendered, "\n")
+	} else if derived.shown != nil {
+		lines = []string{"No tasks match the filter. Type \":filter\" to clear it."}
+	} else {
+		lines = []string{"No tasks yet. Type \":add <task name>\" to add one."}
+	}
+	if height := m.treeHeight(); height > 0 {
+		offset := min(m.offset, max(len(lines)-height, 0))
+		lines = lines[offset:min(offset+height, len(lines))]
+	}
+
+	view := strings.Join(append(header, lines...), "\n")
+	if m.width > 0 {
+		// Truncate rather than wrap long lines so that every task stays on one line.
+		view = lipgloss.NewStyle().MaxWidth(m.width).Render(view)
+	}
+	return view
+}
+
+// visibleIds returns the ids of the rendered tasks in the order they are rendered.
+func (m Model) visibleIds() []task.Id {
+	var roots []task.Id
+	if m.root != nil {
+		roots = []task.Id{*m.root}
+	} else {
+		roots = util.Map(m.ctx.TaskTree().GetRootTasks(), func(t task.Task) task.Id { return t.Id })
+	}
+
+	shown := m.derived().shown
+	isShown := func(id task.Id) bool {
+		return shown == nil || shown[id] || (m.root != nil && id == *m.root)
+	}
+
+	var res []task.Id
+	var visit func(id task.Id)
+	visit = func(id task.Id) {
+		if !isShown(id) {
+			return
+		}
+		res = append(res, id)
+		if m.collapsed[id] {
+			return
+		}
+		subtasks, _ := m.ctx.TaskTree().GetDirectSubtasksOf(id)
+		for _, subtask := range subtasks {
+			visit(subtask.Id)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return res
+}
+
+// viewCache holds the data derived from the task tree that the view needs on
+// every render. The view is re-rendered on every tick, so it is only
+// recomputed when the tree, the filter or the critical path goal change.
+type viewCache struct {
+	key  viewCacheKey
+	data derivedData
+	ok   bool
+}
+
+type viewCacheKey struct {
+	taskTree         *tasktree.TaskTree
+	revision         uint64
+	filterQuery      string
+	filtered         bool
+	criticalPathGoal task.Id
+}
+
+type derivedData struct {
+	shown, matched    map[task.Id]bool // nil if the view isn't filtered
+	critical          map[task.Id]bool // nil if no critical path is highlighted
+	path              tasktree.CriticalPath
+	hasPath           bool
+	directlyBlocked   map[task.Id]bool
+	blockedByAncestor map[task.Id]bool
+}
+
+// derived returns the data derived from the task tree, recomputing it only if
+// the tree changed since it was last computed.
+func (m Model) derived() derivedData {
+	rawQuery, filter := m.ctx.Filter()
+	key := viewCacheKey{
+		taskTree:         m.ctx.TaskTree(),
+		revision:         m.ctx.TaskTree().Revision(),
+		filterQuery:      rawQuery,
+		filtered:         filter != nil,
+		criticalPathGoal: m.ctx.CriticalPathGoal(),
+	}
+	if m.cache.ok && m.cache.key == key {
+		return m.cache.data
+	}
+
+	var data derivedData
+	data.shown, data.matched = m.filterSets(filter)
+	data.path, data.hasPath = m.criticalPath()
+	if data.hasPath {
+		data.critical = make(map[task.Id]bool, len(data.path.Chain))
+		for _, t := range data.path.Chain {
+			data.critical[t.Id] = true
+		}
+	}
+	data.directlyBlocked, data.blockedByAncestor = m.ctx.TaskTree().GetBlockedTasks()
+
+	*m.cache = viewCache{key: key, data: data, ok: true}
+	return data
+}
+
+// filterSets returns the tasks to show when the view is filtered: those that
+// match the filter, along with their ancestors to give the matches context.
+// Both sets are nil if the view isn't filtered.
+func (m Model) filterSets(filter query.Expr) (shown map[task.Id]bool, matched map[task.Id]bool) {
+	if filter == nil {
+		return nil, nil
+	}
+
+	shown = make(map[task.Id]bool)
+	matched = make(map[task.Id]bool)
+	for _, match := range query.Filter(m.ctx.TaskTree(), filter) {
+		matched[match.Id] = true
+		shown[match.Id] = true
+		ancestors, _ := m.ctx.TaskTree().GetAncestorTasks(match.Id)
+		for _, ancestor := range ancestors {
+			shown[ancestor.Id] = true
+		}
+	}
+	return shown, matched
+}
+
+// criticalPath returns the critical path highlighted in the view, if any. It
+// is recomputed whenever the tree changes so that it follows those changes.
+func (m Model) criticalPath() (tasktree.CriticalPath, bool) {
+	goal := m.ctx.CriticalPathGoal()
+	if goal == "" {
+		return tasktree.CriticalPath{}, false
+	}
+	path, err := m.ctx.TaskTree().GetCriticalPath(goal)
+	return path, err == nil
+}
+
+// header returns the lines shown above the tree: the breadcrumb when zoomed
+// in, the query when filtered and the highlighted critical path.
+func (m Model) header() []string {
+	var header []string
+	if m.root != nil {
+		header = append(header, m.breadcrumbStyle.Render(m.breadcrumb(*m.root)))
+	}
+	if rawQuery, filter := m.ctx.Filter(); filter != nil {
+		header = append(header, m.breadcrumbStyle.Render("filter: "+rawQuery))
+	}
+	if derived := m.derived(); derived.hasPath {
+		header = append(header, m.breadcrumbStyle.Render(fmt.Sprintf("critical path to %v: %v", derived.path.Goal.Alias, derived.path.Duration)))
+	}
+	return header
+}
+
+// cursorIndex returns the index of the cursor in visible, or 0 if the cursor
+// task is no longer visible.
+func (m Model) cursorIndex(visible []task.Id) int {
+	for i, id := range visible {
+		if id == m.cursor {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m Model) hasSubtasks(id task.Id) bool {
+	return hasSubtasks(m.ctx.TaskTree(), id)
+}
+
+// breadcrumb renders the path from the top of the tree to the given task.
+func (m Model) breadcrumb(id task.Id) string {
+	t, _ := m.ctx.TaskTree().GetTask(id)
+	names := []string{t.Name}
+	ancestors, _ := m.ctx.TaskTree().GetAncestorTasks(id)
+	for _, ancestor := range ancestors {
+		names = append([]string{ancestor.Name}, names...)
+	}
+	return strings.Join(names, " › ")
+}
+
+// treeHeight returns the number of lines available to the tree itself, or 0 if unknown.
+func (m Model) treeHeight() int {
+	if m.height == 0 {
+		return 0
+	}
+	return max(m.height-len(m.header()), 1)
+}
+
+// scrollToCursor adjusts the scroll offset so that the cursor is visible.
+func (m *Model) scrollToCursor() {
+	height := m.treeHeight()
+	if height == 0 {
+		return
+	}
+
+	i := m.cursorIndex(m.visibleIds())
+	if i < m.offset {
+		m.offset = i
+	} else if i >= m.offset+height {
+		m.offset = i - height + 1
+	}
 }
@@ -4,45 +4,173 @@ import (
 	"fmt"
 	"github.com/carreter/tasktree-go/pkg/task"
 	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/tree"
 	"strings"
+	"time"
 )
 
-func RenderTaskTreeFromRoot(taskTree *tasktree.TaskTree, rootId task.Id, depth int) (string, error) {
+// RenderOptions controls how a TaskTree is rendered.
+type RenderOptions struct {
+	// Depth is the maximum number of levels to render. Negative means unlimited.
+	Depth int
+	// Selected is the task to highlight.
+	Selected task.Id
+	// Collapsed contains the tasks whose subtasks should not be rendered.
+	Collapsed map[task.Id]bool
+	// Shown contains the tasks to render when the tree is filtered, nil renders every task.
+	Shown map[task.Id]bool
+	// Matched contains the tasks matching the filter. Shown tasks that don't
+	// match are rendered with ContextStyle.
+	Matched map[task.Id]bool
+	// Critical contains the tasks on the highlighted critical path, rendered
+	// with CriticalStyle.
+	Critical map[task.Id]bool
+	// DirectlyBlocked contains the tasks that are blocked themselves and
+	// BlockedByAncestor those only blocked through an ancestor, as returned by
+	// TaskTree.GetBlockedTasks.
+	DirectlyBlocked   map[task.Id]bool
+	BlockedByAncestor map[task.Id]bool
+	// ProgressWidth is the width of the progress bars shown next to tasks with
+	// subtasks. Zero hides them.
+	ProgressWidth int
+	// Now is the time due and start dates are compared to.
+	Now time.Time
+	// DueSoonDays is how many days ahead open tasks count as due soon and are
+	// rendered with DueSoonStyle. Overdue tasks are rendered with OverdueStyle.
+	DueSoonDays int
+
+	ItemStyle     lipgloss.Style
+	SelectedStyle lipgloss.Style
+	BlockedStyle  lipgloss.Style
+	ContextStyle  lipgloss.Style
+	ProgressStyle lipgloss.Style
+	CriticalStyle lipgloss.Style
+	DueStyle      lipgloss.Style
+	DueSoonStyle  lipgloss.Style
+	OverdueStyle  lipgloss.Style
+}
+
+func (opts RenderOptions) isShown(id task.Id) bool {
+	return opts.Shown == nil || opts.Shown[id]
+}
+
+func renderLabel(taskTree *tasktree.TaskTree, t task.Task, opts RenderOptions) string {
+	label := t.Alias + " " + t.Name
+	if !t.Completed && !t.Recurrence.IsZero() {
+		label += " ↻"
+	}
+	if opts.Collapsed[t.Id] {
+		if subtasks, _ := taskTree.GetDirectSubtasksOf(t.Id); len(subtasks) > 0 {
+			label += fmt.Sprintf(" [+%d]", len(subtasks))
+		}
+	}
+
+	if t.Id == opts.Selected {
+		label = opts.SelectedStyle.Render(label)
+	} else if opts.Critical[t.Id] {
+		label = opts.CriticalStyle.Render(label)
+	} else if opts.Matched != nil && !opts.Matched[t.Id] {
+		label = opts.ContextStyle.Render(label)
+	} else {
+		label = opts.ItemStyle.Render(label)
+	}
+
+	if opts.ProgressWidth > 0 && hasSubtasks(taskTree, t.Id) {
+		if rollup, err := taskTree.GetRollup(t.Id); err == nil {
+			label += " " + opts.ProgressStyle.Render(renderProgress(rollup.Percent(), opts.ProgressWidth))
+		}
+	}
+
+	if !t.Completed && !t.Due.IsZero() {
+		due := "due " + task.FormatDate(t.Due, opts.Now)
+		if t.Overdue(opts.Now) {
+			label += " " + opts.OverdueStyle.Render("["+due+"]")
+		} else if t.DueWithin(opts.Now, opts.DueSoonDays) {
+			label += " " + opts.DueSoonStyle.Render("["+due+"]")
+		} else {
+			label += " " + opts.DueStyle.Render("["+due+"]")
+		}
+	}
+	if !t.Completed && t.Deferred(opts.Now) {
+		label += " " + opts.DueStyle.Render("[deferred until "+task.FormatDate(t.Start, opts.Now)+"]")
+	}
+
+	// Tasks that are blocked themselves are marked differently from those that
+	// only inherit a blocker from one of their ancestors.
+	if opts.DirectlyBlocked[t.Id] {
+		label += " " + opts.BlockedStyle.Render("[blocked]")
+	} else if opts.BlockedByAncestor[t.Id] {
+		label += " " + opts.BlockedStyle.Faint(true).Render("[blocked by ancestor]")
+	}
+
+	return label
+}
+
+func hasSubtasks(taskTree *tasktree.TaskTree, id task.Id) bool {
+	subtasks, _ := taskTree.GetDirectSubtasksOf(id)
+	return len(subtasks) > 0
+}
+
+// renderProgress renders a progress bar of a given width followed by the percentage.
+func renderProgress(percent float64, width int) string {
+	filled := int(percent / 100 * float64(width))
+	return fmt.Sprintf("%v%v %3.0f%%", strings.Repeat("█", filled), strings.Repeat("░", width-filled), percent)
+}
+
+func buildTaskTree(taskTree *tasktree.TaskTree, rootId task.Id, depth int, opts RenderOptions) (*tree.Tree, error) {
 	root, exists := taskTree.GetTask(rootId)
 	if !exists {
-		return "", fmt.Errorf("task %v not found", rootId)
+		return nil, fmt.Errorf("task %v not found", rootId)
 	}
 
-	t := tree.New().Root(root.Name)
+	t := tree.New().Root(renderLabel(taskTree, root, opts))
 
-	if depth == 0 {
-		return t.String(), nil
+	if depth == 0 || opts.Collapsed[rootId] {
+		return t, nil
 	}
 
 	subtasks, err := taskTree.GetDirectSubtasksOf(rootId)
 	if err != nil {
-		return "", nil
+		return nil, err
 	}
 	for _, subtask := range subtasks {
-		subTree, err := RenderTaskTreeFromRoot(taskTree, subtask.Id, depth-1)
+		if !opts.isShown(subtask.Id) {
+			continue
+		}
+		subTree, err := buildTaskTree(taskTree, subtask.Id, depth-1, opts)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		t.Child(subTree)
 	}
 
+	return t, nil
+}
+
+// RenderTaskTreeFromRoot renders the subtree rooted at a given task. Every
+// rendered task takes up exactly one line.
+func RenderTaskTreeFromRoot(taskTree *tasktree.TaskTree, rootId task.Id, opts RenderOptions) (string, error) {
+	t, err := buildTaskTree(taskTree, rootId, opts.Depth, opts)
+	if err != nil {
+		return "", err
+	}
+
 	return t.String(), nil
 }
 
-func RenderTaskTree(taskTree *tasktree.TaskTree, depth int) (string, error) {
-	trees := make([]string, len(taskTree.GetRootTasks()))
-	for i, rootTask := range taskTree.GetRootTasks() {
-		newTree, err := RenderTaskTreeFromRoot(taskTree, rootTask.Id, depth-1)
+// RenderTaskTree renders every root task of a TaskTree along with its subtasks.
+func RenderTaskTree(taskTree *tasktree.TaskTree, opts RenderOptions) (string, error) {
+	trees := make([]string, 0)
+	for _, rootTask := range taskTree.GetRootTasks() {
+		if !opts.isShown(rootTask.Id) {
+			continue
+		}
+		newTree, err := RenderTaskTreeFromRoot(taskTree, rootTask.Id, opts)
 		if err != nil {
 			return "", err
 		}
-		trees[i] = newTree
+		trees = append(trees, newTree)
 	}
 
 	return strings.Join(trees, "\n"), nil
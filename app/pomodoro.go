@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"time"
+)
+
+// PomodoroSettings are the lengths of the intervals of a Pomodoro, which must
+// be positive.
+type PomodoroSettings struct {
+	Work       time.Duration
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	// LongBreakEvery is the number of work intervals after which the break is
+	// a long one.
+	LongBreakEvery int
+}
+
+// DefaultPomodoroSettings are the classic lengths of pomodoro intervals.
+var DefaultPomodoroSettings = PomodoroSettings{
+	Work:           25 * time.Minute,
+	ShortBreak:     5 * time.Minute,
+	LongBreak:      15 * time.Minute,
+	LongBreakEvery: 4,
+}
+
+// A PomodoroPhase is the kind of interval a Pomodoro is in.
+type PomodoroPhase int
+
+const (
+	WorkPhase PomodoroPhase = iota
+	ShortBreakPhase
+	LongBreakPhase
+)
+
+func (p PomodoroPhase) String() string {
+	switch p {
+	case WorkPhase:
+		return "work"
+	case ShortBreakPhase:
+		return "break"
+	case LongBreakPhase:
+		return "long break"
+	default:
+		return fmt.Sprintf("PomodoroPhase(%d)", int(p))
+	}
+}
+
+// A Pomodoro alternates work intervals on a task with breaks.
+type Pomodoro struct {
+	Settings PomodoroSettings
+	TaskId   task.Id
+
+	Phase      PomodoroPhase
+	PhaseStart time.Time
+	// Completed is the number of work intervals completed since the Pomodoro
+	// started.
+	Completed int
+}
+
+// NewPomodoro starts a Pomodoro on a task with a work interval.
+func NewPomodoro(settings PomodoroSettings, id task.Id, now time.Time) *Pomodoro {
+	return &Pomodoro{Settings: settings, TaskId: id, Phase: WorkPhase, PhaseStart: now}
+}
+
+// PhaseLength returns the length of the current interval.
+func (p *Pomodoro) PhaseLength() time.Duration {
+	switch p.Phase {
+	case ShortBreakPhase:
+		return p.Settings.ShortBreak
+	case LongBreakPhase:
+		return p.Settings.LongBreak
+	default:
+		return p.Settings.Work
+	}
+}
+
+// Remaining returns the time left in the current interval.
+func (p *Pomodoro) Remaining(now time.Time) time.Duration {
+	return max(p.PhaseStart.Add(p.PhaseLength()).Sub(now), 0)
+}
+
+// Advance moves the Pomodoro on to the next interval if the current one has
+// ended by now. The next interval starts now rather than when the current one
+// ended, so that time the Pomodoro wasn't advanced, e.g. while the computer
+// was suspended, doesn't count as intervals that were never worked. Returns
+// the session of the work interval that ended, which is yet to be logged, or
+// a zero Session if a break ended, and whether an interval ended.
+func (p *Pomodoro) Advance(now time.Time) (work tasktree.Session, advanced bool) {
+	end := p.PhaseStart.Add(p.PhaseLength())
+	if now.Before(end) {
+		return tasktree.Session{}, false
+	}
+
+	if p.Phase == WorkPhase {
+		work = tasktree.Session{TaskId: p.TaskId, Start: p.PhaseStart, End: end, Pomodoro: true}
+		p.Completed++
+		p.Phase = ShortBreakPhase
+		if every := p.Settings.LongBreakEvery; every > 0 && p.Completed%every == 0 {
+			p.Phase = LongBreakPhase
+		}
+	} else {
+		p.Phase = WorkPhase
+	}
+	p.PhaseStart = now
+	return work, true
+}
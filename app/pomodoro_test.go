@@ -0,0 +1,53 @@
+package app
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"testing"
+	"time"
+)
+
+func TestPomodoroAdvance(t *testing.T) {
+	settings := PomodoroSettings{Work: 25 * time.Minute, ShortBreak: 5 * time.Minute, LongBreak: 15 * time.Minute, LongBreakEvery: 2}
+	id := task.NewId()
+	start := time.Date(2026, time.October, 18, 9, 0, 0, 0, time.Local)
+	p := NewPomodoro(settings, id, start)
+
+	steps := []struct {
+		now       time.Time
+		wantWork  tasktree.Session
+		advanced  bool
+		wantPhase PomodoroPhase
+	}{
+		{start.Add(24 * time.Minute), tasktree.Session{}, false, WorkPhase},
+		{
+			start.Add(26 * time.Minute),
+			tasktree.Session{TaskId: id, Start: start, End: start.Add(25 * time.Minute), Pomodoro: true},
+			true, ShortBreakPhase,
+		},
+		// The break started when the work interval was noticed to be over.
+		{start.Add(30 * time.Minute), tasktree.Session{}, false, ShortBreakPhase},
+		{start.Add(31 * time.Minute), tasktree.Session{}, true, WorkPhase},
+		// After a suspend, only the interval that was running is logged.
+		{
+			start.Add(5 * time.Hour),
+			tasktree.Session{TaskId: id, Start: start.Add(31 * time.Minute), End: start.Add(56 * time.Minute), Pomodoro: true},
+			true, LongBreakPhase,
+		},
+		{start.Add(5*time.Hour + 14*time.Minute), tasktree.Session{}, false, LongBreakPhase},
+		{start.Add(5*time.Hour + 15*time.Minute), tasktree.Session{}, true, WorkPhase},
+	}
+
+	for i, step := range steps {
+		work, advanced := p.Advance(step.now)
+		if work != step.wantWork || advanced != step.advanced {
+			t.Errorf("step %d: got %+v, %v, want %+v, %v", i, work, advanced, step.wantWork, step.advanced)
+		}
+		if p.Phase != step.wantPhase {
+			t.Errorf("step %d: got phase %v, want %v", i, p.Phase, step.wantPhase)
+		}
+	}
+	if p.Completed != 2 {
+		t.Errorf("got %d completed work intervals, want 2", p.Completed)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/carreter/tasktree-go/app"
+	"github.com/carreter/tasktree-go/app/models/command"
+	"io"
+	"os"
+)
+
+// subcommandAliases maps shorter subcommand names to the commands they run.
+var subcommandAliases = map[string]string{
+	"done": "complete",
+	"rm":   "delete",
+}
+
+// interactiveCommands are the commands that only make sense in the interactive
+// interface, as what they change doesn't outlive the process, keyed by name
+// with the reason why.
+var interactiveCommands = map[string]string{
+	"undo":          "the history of changes isn't saved",
+	"redo":          "the history of changes isn't saved",
+	"filter":        "it filters the tree view",
+	"critical-path": "it highlights the tree view",
+	"pomodoro":      "the pomodoro only runs while the interface is open, use \"start\" and \"stop\" to time work",
+}
+
+// runSubcommand runs a command non-interactively, printing its output to stdout
+// and its error to stderr. Returns the process exit code.
+func runSubcommand(ctx *app.Context, args []string, jsonOutput bool) int {
+	if name, isAlias := subcommandAliases[args[0]]; isAlias {
+		args = append([]string{name}, args[1:]...)
+	}
+
+	if reason, isInteractive := interactiveCommands[args[0]]; isInteractive {
+		fmt.Fprintf(os.Stderr, "error: %s is only available in the interactive interface: %s\n", args[0], reason)
+		return 2
+	}
+
+	cmd, exists := command.Commands()[args[0]]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "error: unknown command: %s\n", args[0])
+		return 2
+	}
+
+	if jsonOutput {
+		if err := runJSON(os.Stdout, ctx, cmd, args); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	} else {
+		out, errMsg := cmd.Run(ctx, args...)
+		if errMsg != "" {
+			fmt.Fprintf(os.Stderr, "error: %s\n", errMsg)
+			return 1
+		}
+		fmt.Println(out)
+	}
+
+	if err := ctx.Sync(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: could not save task tree: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// runJSON runs a command and writes its result to w as JSON. Commands that
+// don't return structured data have their output message wrapped in an object.
+func runJSON(w io.Writer, ctx *app.Context, cmd command.Command, args []string) error {
+	var data any
+	if dataCmd, ok := cmd.(command.DataCommand); ok {
+		var err error
+		data, err = dataCmd.RunData(ctx, args...)
+		if err != nil {
+			return err
+		}
+	} else {
+		out, errMsg := cmd.Run(ctx, args...)
+		if errMsg != "" {
+			return fmt.Errorf("%s", errMsg)
+		}
+		data = map[string]string{"message": out}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
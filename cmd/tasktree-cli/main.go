@@ -1,18 +1,60 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"github.com/carreter/tasktree-go/app"
 	"github.com/carreter/tasktree-go/app/models"
-	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/storage"
 	tea "github.com/charmbracelet/bubbletea"
 	"os"
 )
 
 func main() {
-	model := models.NewModel(tasktree.NewTaskTree())
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [<command> [<args>...]]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Without a command, the interactive interface is started.")
+		fmt.Fprintln(flag.CommandLine.Output(), "Run the \"help\" command to list the available commands.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	file := flag.String("file", "", "path to the data file (defaults to $XDG_DATA_HOME/tasktree/"+storage.DefaultFileName+")")
+	jsonOutput := flag.Bool("json", false, "print command output as JSON")
+	flag.Parse()
+
+	path := *file
+	if path == "" {
+		defaultPath, err := storage.DefaultPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal error: %v\n", err)
+			os.Exit(1)
+		}
+		path = defaultPath
+	}
+
+	store := storage.NewStore(path)
+	taskTree, err := store.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := app.NewContext(taskTree, store)
+
+	if flag.NArg() > 0 {
+		os.Exit(runSubcommand(ctx, flag.Args(), *jsonOutput))
+	}
+
+	ctx.SetInteractive(true)
+	model := models.NewModel(ctx)
 	program := tea.NewProgram(model)
 	if _, err := program.Run(); err != nil {
-		fmt.Printf("fatal error: %v", err)
+		fmt.Fprintf(os.Stderr, "fatal error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ctx.Sync(); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal error: could not save task tree: %v\n", err)
 		os.Exit(1)
 	}
 }
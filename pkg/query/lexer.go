@@ -0,0 +1,114 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+type token struct {
+	kind tokenKind
+	pos  int    // byte offset of the token in the query
+	raw  string // source text of the token, including any quotes
+}
+
+// A SyntaxError is returned when a query cannot be parsed.
+type SyntaxError struct {
+	Pos int // byte offset in the query at which the error occurred
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// lex splits a query into tokens. Terms may contain double-quoted sections,
+// inside which whitespace, parentheses and operators have no special meaning.
+func lex(query string) ([]token, error) {
+	tokens := make([]token, 0)
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: i, raw: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: i, raw: ")"})
+			i++
+		case c == '!' && i+1 < len(query) && query[i+1] != '=':
+			// "!" directly in front of a term negates it.
+			tokens = append(tokens, token{kind: tokenNot, pos: i, raw: "!"})
+			i++
+		default:
+			start := i
+			quoted := false
+			for i < len(query) {
+				c := query[i]
+				if c == '"' {
+					quoted = !quoted
+				} else if !quoted && (unicode.IsSpace(rune(c)) || c == '(' || c == ')') {
+					break
+				}
+				i++
+			}
+			if quoted {
+				return nil, &SyntaxError{Pos: start, Msg: "unterminated quote"}
+			}
+
+			raw := query[start:i]
+			kind := tokenTerm
+			switch strings.ToLower(raw) {
+			case "and", "&&":
+				kind = tokenAnd
+			case "or", "||":
+				kind = tokenOr
+			case "not", "!":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind: kind, pos: start, raw: raw})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+// splitTerm splits a term into a key, comparison operator and value, e.g.
+// "estimate>=1h" into "estimate", ">=" and "1h". Operators inside quotes are
+// ignored. If the term has no operator, key and op are empty.
+func splitTerm(raw string) (key string, op string, value string) {
+	quoted := false
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ':' || c == '=':
+			return raw[:i], string(c), unquote(raw[i+1:])
+		case c == '<' || c == '>':
+			if i+1 < len(raw) && raw[i+1] == '=' {
+				return raw[:i], raw[i : i+2], unquote(raw[i+2:])
+			}
+			return raw[:i], string(c), unquote(raw[i+1:])
+		}
+	}
+	return "", "", unquote(raw)
+}
+
+func unquote(s string) string {
+	return strings.ReplaceAll(s, "\"", "")
+}
@@ -0,0 +1,111 @@
+// Package query implements a small query language for filtering the tasks in a TaskTree.
+//
+// A query is made up of terms combined with "and", "or", "not" and parentheses.
+// Terms that are next to each other are implicitly joined by "and", and "!" can be
+// used as a shorthand for "not". For example:
+//
+//	priority:urgent open tag:backend !blocked
+//	(tag:frontend or tag:design) and descendant-of:release estimate>=2h
+//
+// The following terms are supported:
+//
+//	completed, done          the task is completed
+//	open                     the task is not completed
+//	blocked                  the task or one of its ancestors has an incomplete blocker
+//	has-subtasks             the task has at least one subtask
+//	root                     the task is not a subtask
+//	name:<text>              the name contains text (case-insensitive), name=<text> for an exact match
+//	description:<text>       the description contains text (case-insensitive)
+//	<text>                   the name or description contains text (case-insensitive)
+//	id:<id>                  the task has the given id or alias
+//	tag:<tag>                the task has the given tag
+//	priority:<p>[,<p>...]    the task has one of the given priorities
+//	estimate<op><duration>   compares the estimated time, op is one of : = < <= > >=
+//	invested<op><duration>   compares the time invested, op is one of : = < <= > >=
+//	descendant-of:<task>     the task is a (transitive) subtask of the given task
+//	ancestor-of:<task>       the given task is a (transitive) subtask of the task
+//	child-of:<task>          the task is a direct subtask of the given task
+//	parent-of:<task>         the given task is a direct subtask of the task
+//	blocks:<task>            the task directly blocks the given task
+//	blocked-by:<task>        the task is directly blocked by the given task
+//
+// Tasks are referred to by id, alias, name or id prefix, see TaskTree.Resolve.
+// Values containing spaces or special characters can be double-quoted.
+package query
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+)
+
+// An Expr is a parsed query that can be matched against the tasks of a TaskTree.
+type Expr interface {
+	Match(tree *tasktree.TaskTree, t task.Task) bool
+}
+
+// Parse parses a query. An empty query matches every task.
+func Parse(query string) (Expr, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return predicate(func(*tasktree.TaskTree, task.Task) bool { return true }), nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: "unexpected \"" + tok.raw + "\""}
+	}
+
+	return expr, nil
+}
+
+// Filter returns the tasks of a TaskTree matching an Expr in depth-first order.
+func Filter(tree *tasktree.TaskTree, expr Expr) []task.Task {
+	return util.Filter(tree.GetAllTasks(), func(t task.Task) bool {
+		return expr.Match(tree, t)
+	})
+}
+
+type andExpr struct {
+	left, right Expr
+}
+
+func (e andExpr) Match(tree *tasktree.TaskTree, t task.Task) bool {
+	return e.left.Match(tree, t) && e.right.Match(tree, t)
+}
+
+type orExpr struct {
+	left, right Expr
+}
+
+func (e orExpr) Match(tree *tasktree.TaskTree, t task.Task) bool {
+	return e.left.Match(tree, t) || e.right.Match(tree, t)
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+func (e notExpr) Match(tree *tasktree.TaskTree, t task.Task) bool {
+	return !e.expr.Match(tree, t)
+}
+
+// A predicate is a single term of a query.
+type predicate func(tree *tasktree.TaskTree, t task.Task) bool
+
+func (p predicate) Match(tree *tasktree.TaskTree, t task.Task) bool {
+	return p(tree, t)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestTree creates the tree the tests query:
+//
+//	release #work
+//	  write changelog !high 2h "list every fix"
+//	  ship v2 !urgent, blocked by write changelog
+//	buy milk #home, completed
+//	fix sink #home 30m "the kitchen sink leaks"
+func newTestTree(t *testing.T) *tasktree.TaskTree {
+	t.Helper()
+	tree := tasktree.NewTaskTree()
+
+	release := task.Task{Id: task.NewId(), Name: "release", Tags: []task.Tag{"work"}}
+	changelog := task.Task{Id: task.NewId(), Name: "write changelog", Priority: task.High, EstimatedTime: 2 * time.Hour, Description: "list every fix"}
+	ship := task.Task{Id: task.NewId(), Name: "ship v2", Priority: task.Urgent}
+	milk := task.Task{Id: task.NewId(), Name: "buy milk", Tags: []task.Tag{"home"}, Completed: true}
+	sink := task.Task{Id: task.NewId(), Name: "fix sink", Tags: []task.Tag{"home"}, EstimatedTime: 30 * time.Minute, Description: "the kitchen sink leaks"}
+
+	for _, err := range []error{
+		tree.AddTask(release),
+		tree.AddSubtask(release.Id, changelog),
+		tree.AddSubtask(release.Id, ship),
+		tree.AddTask(milk),
+		tree.AddTask(sink),
+		tree.MarkBlocker(changelog.Id, ship.Id),
+	} {
+		if err != nil {
+			t.Fatalf("failed to build test tree: %v", err)
+		}
+	}
+	return tree
+}
+
+// matchingNames parses a query and returns the names of the matching tasks.
+func matchingNames(t *testing.T, tree *tasktree.TaskTree, query string) []string {
+	t.Helper()
+	expr, err := Parse(query)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", query, err)
+	}
+	return util.Map(Filter(tree, expr), func(t task.Task) string { return t.Name })
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty query matches everything", "", []string{"release", "write changelog", "ship v2", "buy milk", "fix sink"}},
+		{"keyword", "done", []string{"buy milk"}},
+		{"text", "SINK", []string{"fix sink"}},
+		{"text matches descriptions", "fix", []string{"write changelog", "fix sink"}},
+
+		{"and binds tighter than or", "tag:home or tag:work and priority:urgent", []string{"buy milk", "fix sink"}},
+		{"and binds tighter than or on the right", "priority:urgent and tag:work or tag:home", []string{"buy milk", "fix sink"}},
+		{"parentheses", "(tag:home or tag:work) and open", []string{"release", "fix sink"}},
+		{"or is left associative", "done or tag:work or priority:high", []string{"release", "write changelog", "buy milk"}},
+		{"symbolic operators", "tag:home && open || priority:urgent", []string{"ship v2", "fix sink"}},
+		{"operators are case-insensitive", "tag:home AND open OR priority:urgent", []string{"ship v2", "fix sink"}},
+
+		{"implicit and", "tag:home open", []string{"fix sink"}},
+		{"implicit and binds tighter than or", "tag:work or tag:home open", []string{"release", "fix sink"}},
+		{"implicit and before parentheses", "open (tag:home or priority:high)", []string{"write changelog", "fix sink"}},
+		{"implicit and before negation", "tag:home !done", []string{"fix sink"}},
+
+		{"not", "not open", []string{"buy milk"}},
+		{"bang", "!open", []string{"buy milk"}},
+		{"separate bang", "! open", []string{"buy milk"}},
+		{"double negation", "!!done", []string{"buy milk"}},
+		{"not binds tighter than and", "not done and tag:home", []string{"fix sink"}},
+		{"not of parentheses", "not (done or tag:home)", []string{"release", "write changelog", "ship v2"}},
+		{"bang before parentheses", "!(tag:home)", []string{"release", "write changelog", "ship v2"}},
+
+		{"quoted text", `"fix sink"`, []string{"fix sink"}},
+		{"quoted value", `name:"ship v2"`, []string{"ship v2"}},
+		{"quoted exact name", `name="SHIP V2"`, []string{"ship v2"}},
+		{"quoted operators are text", `"sink or milk"`, nil},
+		{"quoted keywords are text", `"open"`, nil},
+		{"quoted parentheses are text", `"sink)"`, nil},
+		{"partly quoted term", `description:"kitchen sink"`, []string{"fix sink"}},
+
+		{"blocked", "blocked", []string{"ship v2"}},
+		{"descendant-of", "descendant-of:release", []string{"write changelog", "ship v2"}},
+		{"ancestor-of", `ancestor-of:"ship v2"`, []string{"release"}},
+		{"child-of", "child-of:release", []string{"write changelog", "ship v2"}},
+		{"parent-of", `parent-of:"write changelog"`, []string{"release"}},
+		{"blocks", `blocks:"ship v2"`, []string{"write changelog"}},
+		{"blocked-by", `blocked-by:"write changelog"`, []string{"ship v2"}},
+		{"unknown task matches nothing", "descendant-of:nothing", nil},
+		{"negated relation", "open !descendant-of:release", []string{"release", "fix sink"}},
+
+		{"estimate comparison", "estimate>=1h", []string{"write changelog"}},
+		{"estimate range", "estimate>0s estimate<1h", []string{"fix sink"}},
+		{"priority list", "priority:urgent,high", []string{"write changelog", "ship v2"}},
+	}
+
+	tree := newTestTree(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchingNames(t, tree, tt.query); !slices.Equal(got, tt.want) {
+				t.Errorf("query %q matched %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"(open", 5, `expected ")" to close "(" at position 0`},
+		{"open and (done or (root)", 24, `expected ")" to close "(" at position 9`},
+		{"open)", 4, `unexpected ")"`},
+		{"()", 1, `unexpected ")"`},
+		{"or open", 0, `unexpected "or"`},
+		{"open and", 8, "unexpected end of query"},
+		{"open or not", 11, "unexpected end of query"},
+		{"open and or done", 9, `unexpected "or"`},
+		{`name:"fix`, 0, "unterminated quote"},
+		{`open name:"fix sink`, 5, "unterminated quote"},
+		{"open colour:red", 5, `unknown field "colour"`},
+		{"open  name:", 6, `missing value for "name"`},
+		{"done estimate>=soon", 5, `invalid duration "soon"`},
+		{"tag<home", 0, `operator "<" is not supported here`},
+		{"name>=x", 0, `operator ">=" is not supported for text`},
+		{"priority:whenever", 0, "whenever"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got error %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("got error at position %d, want %d: %v", syntaxErr.Pos, tt.pos, err)
+			}
+			if !strings.Contains(syntaxErr.Msg, tt.msg) {
+				t.Errorf("got error %q, want it to contain %q", syntaxErr.Msg, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package query
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+	"time"
+)
+
+// keywords are the terms that don't take a value.
+var keywords = map[string]predicate{
+	"completed": func(_ *tasktree.TaskTree, t task.Task) bool { return t.Completed },
+	"done":      func(_ *tasktree.TaskTree, t task.Task) bool { return t.Completed },
+	"open":      func(_ *tasktree.TaskTree, t task.Task) bool { return !t.Completed },
+	"blocked": func(tree *tasktree.TaskTree, t task.Task) bool {
+		blocked, _ := tree.IsBlocked(t.Id)
+		return blocked
+	},
+	"has-subtasks": func(tree *tasktree.TaskTree, t task.Task) bool {
+		subtasks, _ := tree.GetDirectSubtasksOf(t.Id)
+		return len(subtasks) > 0
+	},
+	"root": func(tree *tasktree.TaskTree, t task.Task) bool {
+		isSubtask, _ := tree.IsSubtask(t.Id)
+		return !isSubtask
+	},
+}
+
+// A fieldParser creates the predicate for a key:value term.
+type fieldParser func(op string, value string) (predicate, error)
+
+// fields are the terms that take a value, keyed by name.
+var fields = map[string]fieldParser{
+	"name":        textField(func(t task.Task) []string { return []string{t.Name} }),
+	"description": textField(func(t task.Task) []string { return []string{t.Description} }),
+	"desc":        textField(func(t task.Task) []string { return []string{t.Description} }),
+	"text":        textField(func(t task.Task) []string { return []string{t.Name, t.Description} }),
+	"id": equalityField(func(value string) (predicate, error) {
+		return func(_ *tasktree.TaskTree, t task.Task) bool {
+			return string(t.Id) == value || strings.EqualFold(t.Alias, value)
+		}, nil
+	}),
+	"tag": equalityField(func(value string) (predicate, error) {
+		value = strings.TrimPrefix(value, "#")
+		return func(_ *tasktree.TaskTree, t task.Task) bool {
+			return len(util.Filter(t.Tags, func(tag task.Tag) bool { return strings.EqualFold(string(tag), value) })) > 0
+		}, nil
+	}),
+	"priority": equalityField(func(value string) (predicate, error) {
+		priorities := make([]task.Priority, 0)
+		for _, name := range strings.Split(value, ",") {
+			priority, err := task.ParsePriority(name)
+			if err != nil {
+				return nil, err
+			}
+			priorities = append(priorities, priority)
+		}
+		return func(_ *tasktree.TaskTree, t task.Task) bool { return util.Contains(priorities, t.Priority) }, nil
+	}),
+	"estimate": durationField(func(t task.Task) time.Duration { return t.EstimatedTime }),
+	"invested": durationField(func(t task.Task) time.Duration { return t.TimeInvested }),
+	"descendant-of": relationField(func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool {
+		ancestors, _ := tree.GetAncestorTasks(t.Id)
+		return util.Contains(taskIds(ancestors), id)
+	}),
+	"ancestor-of": relationField(func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool {
+		ancestors, _ := tree.GetAncestorTasks(id)
+		return util.Contains(taskIds(ancestors), t.Id)
+	}),
+	"child-of": relationField(func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool {
+		parent, exists, _ := tree.GetParentTask(t.Id)
+		return exists && parent.Id == id
+	}),
+	"parent-of": relationField(func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool {
+		parent, exists, _ := tree.GetParentTask(id)
+		return exists && parent.Id == t.Id
+	}),
+	"blocks": relationField(func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool {
+		blockers, _ := tree.GetDirectBlockers(id)
+		return util.Contains(taskIds(blockers), t.Id)
+	}),
+	"blocked-by": relationField(func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool {
+		blockers, _ := tree.GetDirectBlockers(t.Id)
+		return util.Contains(taskIds(blockers), id)
+	}),
+}
+
+// parseTerm parses a single term of a query.
+func parseTerm(raw string) (Expr, error) {
+	key, op, value := splitTerm(raw)
+	if op == "" {
+		if keyword, exists := keywords[strings.ToLower(value)]; exists && !strings.Contains(raw, "\"") {
+			return keyword, nil
+		}
+		return fields["text"](":", value)
+	}
+
+	field, exists := fields[strings.ToLower(key)]
+	if !exists {
+		return nil, fmt.Errorf("unknown field %q", key)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %q", key)
+	}
+
+	return field(op, value)
+}
+
+// equalityField creates a fieldParser for a field that only supports the ":" and "=" operators.
+func equalityField(parse func(value string) (predicate, error)) fieldParser {
+	return func(op string, value string) (predicate, error) {
+		if op != ":" && op != "=" {
+			return nil, fmt.Errorf("operator %q is not supported here", op)
+		}
+		return parse(value)
+	}
+}
+
+// textField creates a fieldParser for a text field. ":" checks if the text
+// contains the value, "=" checks for equality. Both are case-insensitive.
+func textField(get func(task.Task) []string) fieldParser {
+	return func(op string, value string) (predicate, error) {
+		var matches func(s string) bool
+		switch op {
+		case ":":
+			matches = func(s string) bool { return containsFold(s, value) }
+		case "=":
+			matches = func(s string) bool { return strings.EqualFold(s, value) }
+		default:
+			return nil, fmt.Errorf("operator %q is not supported for text", op)
+		}
+
+		return func(_ *tasktree.TaskTree, t task.Task) bool {
+			return len(util.Filter(get(t), matches)) > 0
+		}, nil
+	}
+}
+
+// durationField creates a fieldParser for a duration field supporting comparisons.
+func durationField(get func(task.Task) time.Duration) fieldParser {
+	return func(op string, value string) (predicate, error) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q", value)
+		}
+
+		var compare func(a, b time.Duration) bool
+		switch op {
+		case ":", "=":
+			compare = func(a, b time.Duration) bool { return a == b }
+		case "<":
+			compare = func(a, b time.Duration) bool { return a < b }
+		case "<=":
+			compare = func(a, b time.Duration) bool { return a <= b }
+		case ">":
+			compare = func(a, b time.Duration) bool { return a > b }
+		case ">=":
+			compare = func(a, b time.Duration) bool { return a >= b }
+		}
+
+		return func(_ *tasktree.TaskTree, t task.Task) bool { return compare(get(t), d) }, nil
+	}
+}
+
+// relationField creates a fieldParser for a relation to another task. The
+// task is given by any reference accepted by TaskTree.Resolve, and is resolved
+// when matching since queries are parsed without a TaskTree. References that
+// don't resolve to a single task match nothing.
+func relationField(related func(tree *tasktree.TaskTree, t task.Task, id task.Id) bool) fieldParser {
+	return equalityField(func(value string) (predicate, error) {
+		return func(tree *tasktree.TaskTree, t task.Task) bool {
+			id, err := tree.Resolve(value)
+			if err != nil {
+				return false
+			}
+			return related(tree, t, id)
+		}, nil
+	})
+}
+
+func taskIds(tasks []task.Task) []task.Id {
+	return util.Map(tasks, func(t task.Task) task.Id { return t.Id })
+}
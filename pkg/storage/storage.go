@@ -0,0 +1,101 @@
+// Package storage persists a TaskTree to disk.
+package storage
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"os"
+	"path/filepath"
+)
+
+// DefaultFileName is the name of the data file inside the default data directory.
+const DefaultFileName = "tasktree.gob"
+
+// DefaultPath returns the default location of the data file, following the
+// XDG base directory specification ($XDG_DATA_HOME/tasktree/tasktree.gob,
+// falling back to ~/.local/share/tasktree/tasktree.gob).
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" || !filepath.IsAbs(dataHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not determine home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "tasktree", DefaultFileName), nil
+}
+
+// A Store loads and saves a TaskTree from a data file.
+type Store struct {
+	path string
+}
+
+// NewStore creates a Store backed by the data file at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the path of the data file backing the Store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the TaskTree from the data file.
+// If the data file does not exist yet, an empty TaskTree is returned.
+func (s *Store) Load() (*tasktree.TaskTree, error) {
+	tree := tasktree.NewTaskTree()
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tree, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not open data file: %w", err)
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(tree); err != nil {
+		return nil, fmt.Errorf("could not decode data file %v: %w", s.path, err)
+	}
+
+	return tree, nil
+}
+
+// Save atomically writes the TaskTree to the data file.
+// The tree is first written to a temporary file in the same directory which is
+// then renamed over the data file, so a crash never leaves a partial file behind.
+func (s *Store) Save(tree *tasktree.TaskTree) (err error) {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create data directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = gob.NewEncoder(tmp).Encode(tree); err != nil {
+		return fmt.Errorf("could not encode task tree: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("could not sync temporary file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("could not close temporary file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("could not replace data file: %w", err)
+	}
+
+	return nil
+}
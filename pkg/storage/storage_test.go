@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/tasktree"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	// The data directory doesn't exist yet.
+	store := NewStore(filepath.Join(t.TempDir(), "data", DefaultFileName))
+
+	tree := tasktree.NewTaskTree()
+	parent := task.Task{Id: task.NewId(), Name: "parent", EstimatedTime: time.Hour}
+	child := task.Task{Id: task.NewId(), Name: "child"}
+	blocker := task.Task{Id: task.NewId(), Name: "blocker"}
+	for _, err := range []error{
+		tree.AddTask(parent),
+		tree.AddSubtask(parent.Id, child),
+		tree.AddTask(blocker),
+		tree.MarkBlocker(blocker.Id, child.Id),
+		tree.LogTime(child.Id, time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Saving twice replaces the data file.
+	for i := 0; i < 2; i++ {
+		if err := store.Save(tree); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := loaded.GetTask(parent.Id); got.Name != "parent" || got.EstimatedTime != time.Hour {
+		t.Errorf("got parent %+v", got)
+	}
+	if got, exists, _ := loaded.GetParentTask(child.Id); !exists || got.Id != parent.Id {
+		t.Errorf("got parent of child %+v, want %v", got, parent.Id)
+	}
+	if blockers, _ := loaded.GetDirectBlockers(child.Id); len(blockers) != 1 || blockers[0].Id != blocker.Id {
+		t.Errorf("got blockers of child %+v, want %v", blockers, blocker.Id)
+	}
+	if got, _ := loaded.GetTask(child.Id); got.TimeInvested != time.Hour {
+		t.Errorf("got %v invested in child, want 1h", got.TimeInvested)
+	}
+	if sessions := loaded.GetSessions(); len(sessions) != 1 || sessions[0].TaskId != child.Id {
+		t.Errorf("got sessions %+v, want the one of child", sessions)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(store.Path()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got files %v, want only the data file", entries)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), DefaultFileName))
+	tree, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tasks := tree.GetAllTasks(); len(tasks) != 0 {
+		t.Errorf("got tasks %+v, want an empty tree", tasks)
+	}
+	if _, err := os.Stat(store.Path()); !os.IsNotExist(err) {
+		t.Errorf("loading created the data file: %v", err)
+	}
+}
+
+func TestLoadCorruptFile(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), DefaultFileName))
+	if err := os.WriteFile(store.Path(), []byte("not a task tree"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("expected an error loading a corrupt data file")
+	}
+}
+
+func TestSaveFailureRemovesTempFile(t *testing.T) {
+	dir := t.TempDir()
+	// The data file can't be replaced because a directory is in the way.
+	store := NewStore(filepath.Join(dir, DefaultFileName))
+	if err := os.MkdirAll(filepath.Join(store.Path(), "in-the-way"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := tasktree.NewTaskTree()
+	if err := tree.AddTask(task.Task{Id: task.NewId(), Name: "task"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(tree); err == nil {
+		t.Fatal("expected an error replacing a directory")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != DefaultFileName {
+		t.Errorf("got files %v, want the temporary file removed", entries)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if got, err := DefaultPath(); err != nil || got != filepath.Join("/data", "tasktree", DefaultFileName) {
+		t.Errorf("got %v, %v, want the path under XDG_DATA_HOME", got, err)
+	}
+
+	// Relative paths are ignored, as the specification requires.
+	t.Setenv("XDG_DATA_HOME", "data")
+	t.Setenv("HOME", "/home/user")
+	if got, err := DefaultPath(); err != nil || got != filepath.Join("/home/user", ".local", "share", "tasktree", DefaultFileName) {
+		t.Errorf("got %v, %v, want the path under ~/.local/share", got, err)
+	}
+}
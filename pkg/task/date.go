@@ -0,0 +1,107 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout dates are written in, e.g. "2026-11-03".
+const DateLayout = "2006-01-02"
+
+// StartOfDay returns midnight at the start of the day of t, in t's location.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// DaysBetween returns the number of calendar days from the day of one time to
+// the day of another, e.g. 1 from any time today to any time tomorrow.
+func DaysBetween(from time.Time, to time.Time) int {
+	// Count days in UTC so that daylight saving time changes don't matter.
+	fromYear, fromMonth, fromDay := from.Date()
+	toYear, toMonth, toDay := to.Date()
+	return int(time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC).Sub(time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// ParseDate parses a day relative to now. It accepts:
+//   - "today", "tomorrow" and "yesterday"
+//   - weekday names such as "fri" or "friday", meaning the next such day,
+//     which is today if today is that day
+//   - offsets such as "+3d", "+2w", "+1m" or "-1y", in days, weeks, months
+//     or years, where months and years end on the last day of shorter months
+//   - dates such as "2026-11-03"
+//
+// The result is midnight at the start of the day, in now's location.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	today := StartOfDay(now)
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch s {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if s == name || s == name[:3] {
+			return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7), nil
+		}
+	}
+
+	if len(s) >= 3 && (s[0] == '+' || s[0] == '-') {
+		n, err := strconv.Atoi(s[1 : len(s)-1])
+		if err == nil {
+			if s[0] == '-' {
+				n = -n
+			}
+			switch s[len(s)-1] {
+			case 'd':
+				return today.AddDate(0, 0, n), nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n), nil
+			case 'm':
+				return addMonths(today, n), nil
+			case 'y':
+				return addMonths(today, 12*n), nil
+			}
+		}
+	}
+
+	if date, err := time.ParseInLocation(DateLayout, s, now.Location()); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q, expected e.g. today, tomorrow, fri, +3d or 2026-11-03", s)
+}
+
+// addMonths adds a number of months to a day, going to the last day of the
+// target month if it is shorter, e.g. from January 31 to February 28.
+func addMonths(day time.Time, months int) time.Time {
+	year, month := day.Year(), day.Month()+time.Month(months)
+	return time.Date(year, month, min(day.Day(), daysIn(year, month)), 0, 0, 0, 0, day.Location())
+}
+
+// FormatDate formats a day relative to now: "today", "tomorrow" and
+// "yesterday", weekday names for the coming week, and dates otherwise.
+func FormatDate(date time.Time, now time.Time) string {
+	date = date.In(now.Location())
+	days := DaysBetween(now, date)
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "yesterday"
+	case days > 1 && days < 7:
+		return strings.ToLower(date.Weekday().String()[:3])
+	default:
+		return date.Format(DateLayout)
+	}
+}
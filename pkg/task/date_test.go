@@ -0,0 +1,98 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// 2026-03-31 is a Tuesday.
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.Local)
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"today", "2026-03-31"},
+		{"Tomorrow", "2026-04-01"},
+		{"yesterday", "2026-03-30"},
+		{"tue", "2026-03-31"},
+		{"fri", "2026-04-03"},
+		{"monday", "2026-04-06"},
+		{"+3d", "2026-04-03"},
+		{"-1d", "2026-03-30"},
+		{"+2w", "2026-04-14"},
+		{"+1m", "2026-04-30"},
+		{"-1m", "2026-02-28"},
+		{"+11m", "2027-02-28"},
+		{"+12m", "2027-03-31"},
+		{"+1y", "2027-03-31"},
+		{"2026-11-03", "2026-11-03"},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got, err := ParseDate(test.s, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := day(t, test.want); !got.Equal(want) {
+				t.Errorf("got %v, want %v", got.Format(DateLayout), test.want)
+			}
+		})
+	}
+
+	// Years end on the last day of February when going from a leap day.
+	leapDay := time.Date(2028, 2, 29, 12, 0, 0, 0, time.Local)
+	for s, want := range map[string]string{"+1y": "2029-02-28", "-1y": "2027-02-28", "+4y": "2032-02-29", "+12m": "2029-02-28"} {
+		got, err := ParseDate(s, leapDay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Format(DateLayout) != want {
+			t.Errorf("%v from a leap day: got %v, want %v", s, got.Format(DateLayout), want)
+		}
+	}
+
+	for _, s := range []string{"", "someday", "+d", "+3x", "2026-02-30"} {
+		if _, err := ParseDate(s, now); err == nil {
+			t.Errorf("expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	// 2026-03-31 is a Tuesday.
+	now := time.Date(2026, 3, 31, 15, 4, 5, 0, time.Local)
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2026-03-31", "today"},
+		{"2026-04-01", "tomorrow"},
+		{"2026-03-30", "yesterday"},
+		{"2026-04-02", "thu"},
+		{"2026-04-06", "mon"},
+		{"2026-04-07", "2026-04-07"},
+		{"2026-03-29", "2026-03-29"},
+	}
+	for _, test := range tests {
+		t.Run(test.date, func(t *testing.T) {
+			if got := FormatDate(day(t, test.date), now); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// Dates are formatted in the time zone of now, so that the weekday and
+	// the date agree with the number of days away.
+	east := time.FixedZone("UTC+10", 10*60*60)
+	west := time.FixedZone("UTC-10", -10*60*60)
+	now = time.Date(2026, 3, 31, 12, 0, 0, 0, west)
+	// 2026-04-03 08:00 UTC+10 is 2026-04-02 12:00 UTC-10, a Thursday.
+	if got := FormatDate(time.Date(2026, 4, 3, 8, 0, 0, 0, east), now); got != "thu" {
+		t.Errorf("got %v, want thu", got)
+	}
+	// 2026-04-08 08:00 UTC+10 is 2026-04-07 12:00 UTC-10.
+	if got := FormatDate(time.Date(2026, 4, 8, 8, 0, 0, 0, east), now); got != "2026-04-07" {
+		t.Errorf("got %v, want 2026-04-07", got)
+	}
+}
@@ -0,0 +1,259 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Frequency is the unit of time a Recurrence repeats in.
+type Frequency byte
+
+const (
+	// Never means the task doesn't recur.
+	Never Frequency = iota
+	// Daily repeats every Interval days.
+	Daily
+	// Weekly repeats every Interval weeks.
+	Weekly
+	// Monthly repeats every Interval months.
+	Monthly
+)
+
+func (f Frequency) String() string {
+	switch f {
+	case Never:
+		return "never"
+	case Daily:
+		return "daily"
+	case Weekly:
+		return "weekly"
+	case Monthly:
+		return "monthly"
+	default:
+		return fmt.Sprintf("Frequency(%d)", byte(f))
+	}
+}
+
+// unit returns the singular name of the unit of time of a Frequency.
+func (f Frequency) unit() string {
+	return map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month"}[f]
+}
+
+// A Recurrence describes how a recurring task repeats. Completing an instance
+// of a recurring task creates the next one, see NextDate.
+type Recurrence struct {
+	Frequency Frequency
+	// Interval is the number of days, weeks or months between instances. Zero
+	// is the same as 1.
+	Interval int
+	// Weekdays are the days of the week a Weekly task repeats on. Empty means
+	// the weekday of the previous instance.
+	Weekdays []time.Weekday
+	// MonthDay is the day of the month a Monthly task repeats on, or the last
+	// day of shorter months. Zero means the day of the previous instance until
+	// the rule is anchored, see Anchored.
+	MonthDay int
+	// AfterCompletion schedules the next instance relative to when the
+	// previous one was completed rather than to when it was due.
+	AfterCompletion bool
+	// Subtree creates the next instance along with a copy of the subtasks of
+	// the previous one.
+	Subtree bool
+}
+
+// IsZero returns whether the Recurrence is the zero value, meaning the task
+// doesn't recur.
+func (r Recurrence) IsZero() bool {
+	return r.Frequency == Never
+}
+
+func (r Recurrence) interval() int {
+	return max(r.Interval, 1)
+}
+
+// Anchored returns the rule with the day of the month of a Monthly rule set
+// to that of date if it had none, so that instances keep coming back to that
+// day after shorter months rather than drifting to their last day. Rules that
+// repeat after completion and zero dates are left as is.
+func (r Recurrence) Anchored(date time.Time) Recurrence {
+	if r.Frequency == Monthly && r.MonthDay == 0 && !r.AfterCompletion && !date.IsZero() {
+		r.MonthDay = date.Day()
+	}
+	return r
+}
+
+// NextDate returns the first day the task repeats on after a given day.
+// Monthly rules without a MonthDay repeat on the day of from, clamped to the
+// end of shorter months.
+func (r Recurrence) NextDate(from time.Time) time.Time {
+	from = StartOfDay(from)
+	n := r.interval()
+
+	switch r.Frequency {
+	case Daily:
+		return from.AddDate(0, 0, n)
+	case Weekly:
+		if len(r.Weekdays) == 0 {
+			return from.AddDate(0, 0, 7*n)
+		}
+		weekStart := from.AddDate(0, 0, -int(from.Weekday()))
+		for days := 1; days <= 7*n+7; days++ {
+			date := from.AddDate(0, 0, days)
+			if slices.Contains(r.Weekdays, date.Weekday()) && DaysBetween(weekStart, date)/7%n == 0 {
+				return date
+			}
+		}
+	case Monthly:
+		day := r.MonthDay
+		months := n
+		if day == 0 {
+			day = from.Day()
+		} else if min(day, daysIn(from.Year(), from.Month())) > from.Day() {
+			// The previous instance came before the day of this month.
+			months = 0
+		}
+		year, month := from.Year(), from.Month()+time.Month(months)
+		return time.Date(year, month, min(day, daysIn(year, month)), 0, 0, 0, 0, from.Location())
+	}
+	return from
+}
+
+// daysIn returns the number of days in a month. Months past December or
+// before January roll over into the following or previous years.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// String formats a Recurrence in the format accepted by ParseRecurrence, e.g.
+// "every 2 weeks on mon,thu with subtasks".
+func (r Recurrence) String() string {
+	if r.IsZero() {
+		return Never.String()
+	}
+
+	var parts []string
+	if n := r.interval(); n == 1 {
+		parts = append(parts, r.Frequency.String())
+	} else {
+		parts = append(parts, fmt.Sprintf("every %d %vs", n, r.Frequency.unit()))
+	}
+	if r.Frequency == Weekly && len(r.Weekdays) > 0 {
+		names := make([]string, len(r.Weekdays))
+		for i, weekday := range r.Weekdays {
+			names[i] = strings.ToLower(weekday.String()[:3])
+		}
+		parts = append(parts, "on "+strings.Join(names, ","))
+	}
+	if r.Frequency == Monthly && r.MonthDay > 0 {
+		parts = append(parts, "on "+strconv.Itoa(r.MonthDay))
+	}
+	if r.AfterCompletion {
+		parts = append(parts, "after completion")
+	}
+	if r.Subtree {
+		parts = append(parts, "with subtasks")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseRecurrence parses a Recurrence such as "daily", "weekly on mon,thu",
+// "monthly on 15" or "every 3 days after completion". Any rule may end with
+// "with subtasks" to set Subtree.
+func ParseRecurrence(s string) (Recurrence, error) {
+	words := strings.Fields(strings.ToLower(s))
+	invalid := func(reason string) (Recurrence, error) {
+		return Recurrence{}, fmt.Errorf("invalid recurrence %q: %v, expected e.g. daily, weekly on mon,thu, monthly on 15 or every 3 days after completion", s, reason)
+	}
+	if len(words) == 0 {
+		return invalid("empty rule")
+	}
+
+	var r Recurrence
+	switch words[0] {
+	case "daily":
+		r.Frequency = Daily
+		words = words[1:]
+	case "weekly":
+		r.Frequency = Weekly
+		words = words[1:]
+	case "monthly":
+		r.Frequency = Monthly
+		words = words[1:]
+	case "every":
+		if len(words) < 3 {
+			return invalid("missing interval")
+		}
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 1 {
+			return invalid(fmt.Sprintf("interval %q is not a positive number", words[1]))
+		}
+		r.Interval = n
+		for _, f := range []Frequency{Daily, Weekly, Monthly} {
+			if words[2] == f.unit() || words[2] == f.unit()+"s" {
+				r.Frequency = f
+			}
+		}
+		if r.Frequency == Never {
+			return invalid(fmt.Sprintf("unknown unit %q", words[2]))
+		}
+		words = words[3:]
+	default:
+		return invalid(fmt.Sprintf("unknown frequency %q", words[0]))
+	}
+
+	if len(words) >= 2 && words[0] == "on" {
+		var err error
+		switch r.Frequency {
+		case Weekly:
+			r.Weekdays, err = parseWeekdays(words[1])
+		case Monthly:
+			r.MonthDay, err = strconv.Atoi(words[1])
+			if err == nil && (r.MonthDay < 1 || r.MonthDay > 31) {
+				err = fmt.Errorf("day of the month %d out of range", r.MonthDay)
+			}
+		default:
+			err = fmt.Errorf("%v tasks can't repeat on given days", r.Frequency)
+		}
+		if err != nil {
+			return invalid(err.Error())
+		}
+		words = words[2:]
+	}
+	if len(words) >= 2 && words[0] == "after" && words[1] == "completion" {
+		r.AfterCompletion = true
+		words = words[2:]
+	}
+	if len(words) >= 2 && words[0] == "with" && words[1] == "subtasks" {
+		r.Subtree = true
+		words = words[2:]
+	}
+	if len(words) > 0 {
+		return invalid(fmt.Sprintf("unexpected %q", strings.Join(words, " ")))
+	}
+	return r, nil
+}
+
+// parseWeekdays parses a comma-separated list of weekday names, e.g. "mon,thu".
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var res []time.Weekday
+	for _, name := range strings.Split(s, ",") {
+		found := false
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			full := strings.ToLower(weekday.String())
+			if name == full || name == full[:3] {
+				if !slices.Contains(res, weekday) {
+					res = append(res, weekday)
+				}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+	}
+	slices.Sort(res)
+	return res, nil
+}
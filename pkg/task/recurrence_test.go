@@ -0,0 +1,84 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+// day parses a date in the local time zone, failing the test if it's invalid.
+func day(t *testing.T, s string) time.Time {
+	t.Helper()
+	date, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+func TestNextDate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Recurrence
+		from string
+		want string
+	}{
+		{"daily", Recurrence{Frequency: Daily}, "2026-03-10", "2026-03-11"},
+		{"every 3 days", Recurrence{Frequency: Daily, Interval: 3}, "2026-12-30", "2027-01-02"},
+		{"weekly", Recurrence{Frequency: Weekly}, "2026-03-10", "2026-03-17"},
+		// 2026-03-10 is a Tuesday.
+		{"weekly on mon,thu", Recurrence{Frequency: Weekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}, "2026-03-10", "2026-03-12"},
+		{"weekly on mon,thu from thu", Recurrence{Frequency: Weekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}, "2026-03-12", "2026-03-16"},
+		{"every 2 weeks on mon", Recurrence{Frequency: Weekly, Interval: 2, Weekdays: []time.Weekday{time.Monday}}, "2026-03-09", "2026-03-23"},
+		{"monthly", Recurrence{Frequency: Monthly}, "2026-03-10", "2026-04-10"},
+		{"monthly from the 31st", Recurrence{Frequency: Monthly}, "2026-01-31", "2026-02-28"},
+		{"monthly on 31 into february", Recurrence{Frequency: Monthly, MonthDay: 31}, "2026-01-31", "2026-02-28"},
+		{"monthly on 31 out of february", Recurrence{Frequency: Monthly, MonthDay: 31}, "2026-02-28", "2026-03-31"},
+		{"monthly on 31 into april", Recurrence{Frequency: Monthly, MonthDay: 31}, "2026-03-31", "2026-04-30"},
+		{"monthly on 30 into a leap february", Recurrence{Frequency: Monthly, MonthDay: 30}, "2028-01-30", "2028-02-29"},
+		{"monthly on 29 out of a leap february", Recurrence{Frequency: Monthly, MonthDay: 29}, "2028-02-29", "2028-03-29"},
+		{"monthly on 15 before the 15th", Recurrence{Frequency: Monthly, MonthDay: 15}, "2026-03-10", "2026-03-15"},
+		{"monthly on 15 after the 15th", Recurrence{Frequency: Monthly, MonthDay: 15}, "2026-03-20", "2026-04-15"},
+		{"every 2 months", Recurrence{Frequency: Monthly, Interval: 2, MonthDay: 31}, "2026-12-31", "2027-02-28"},
+		{"every 12 months on a leap day", Recurrence{Frequency: Monthly, Interval: 12, MonthDay: 29}, "2028-02-29", "2029-02-28"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.rule.NextDate(day(t, test.from))
+			if want := day(t, test.want); !got.Equal(want) {
+				t.Errorf("got %v, want %v", got.Format(time.DateOnly), test.want)
+			}
+		})
+	}
+}
+
+func TestAnchored(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Recurrence
+		date time.Time
+		want int
+	}{
+		{"monthly", Recurrence{Frequency: Monthly}, day(t, "2026-01-31"), 31},
+		{"monthly on 15", Recurrence{Frequency: Monthly, MonthDay: 15}, day(t, "2026-01-31"), 15},
+		{"after completion", Recurrence{Frequency: Monthly, AfterCompletion: true}, day(t, "2026-01-31"), 0},
+		{"weekly", Recurrence{Frequency: Weekly}, day(t, "2026-01-31"), 0},
+		{"no date", Recurrence{Frequency: Monthly}, time.Time{}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.rule.Anchored(test.date).MonthDay; got != test.want {
+				t.Errorf("got month day %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// An anchored rule no longer drifts to the end of shorter months.
+	rule := Recurrence{Frequency: Monthly}.Anchored(day(t, "2026-01-31"))
+	date := day(t, "2026-01-31")
+	for _, want := range []string{"2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"} {
+		date = rule.NextDate(date)
+		if date.Format(time.DateOnly) != want {
+			t.Errorf("got %v, want %v", date.Format(time.DateOnly), want)
+		}
+	}
+}
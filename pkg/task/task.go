@@ -2,6 +2,9 @@
 package task
 
 import (
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
 	"time"
 )
 
@@ -24,11 +27,47 @@ const (
 	Low
 )
 
+func (p Priority) String() string {
+	switch p {
+	case Default:
+		return "default"
+	case Urgent:
+		return "urgent"
+	case High:
+		return "high"
+	case Normal:
+		return "normal"
+	case Low:
+		return "low"
+	default:
+		return fmt.Sprintf("Priority(%d)", byte(p))
+	}
+}
+
+// ParsePriority parses a priority from its name (e.g. "urgent").
+func ParsePriority(name string) (Priority, error) {
+	for p := Default; p <= Low; p++ {
+		if strings.EqualFold(name, p.String()) {
+			return p, nil
+		}
+	}
+	return Default, fmt.Errorf("unknown priority %q, expected one of: default, urgent, high, normal, low", name)
+}
+
+// An Id uniquely and permanently identifies a Task.
 type Id string
 
+// NewId generates a new random Id.
+func NewId() Id {
+	return Id(uuid.NewString())
+}
+
 // A Task represents an individual task.
 type Task struct {
-	Id            Id
+	Id Id
+	// Alias is a short, human-typeable name for the task (e.g. "t42") that is
+	// unique within a TaskTree. It is assigned by the TaskTree if left empty.
+	Alias         string
 	Name          string
 	Description   string
 	EstimatedTime time.Duration
@@ -36,4 +75,40 @@ type Task struct {
 	Completed     bool
 	Tags          []Tag
 	Priority      Priority
+	// Due is the day the task should be completed by, zero if it has no due date.
+	Due time.Time
+	// Start is the day work on the task is deferred until, zero if it can
+	// start right away.
+	Start time.Time
+	// CompletedAt is when the task was last completed, zero if it is open or
+	// was completed before completion times were recorded.
+	CompletedAt time.Time
+	// Recurrence describes how the task repeats, zero if it doesn't.
+	Recurrence Recurrence
+}
+
+// Overdue returns whether the task is open past its due date.
+func (t Task) Overdue(now time.Time) bool {
+	return !t.Completed && !t.Due.IsZero() && t.Due.Before(StartOfDay(now))
+}
+
+// DueWithin returns whether the task is open, not overdue and due within the
+// given number of days from now, today being 0.
+func (t Task) DueWithin(now time.Time, days int) bool {
+	return !t.Completed && !t.Due.IsZero() && !t.Overdue(now) && t.Due.Before(StartOfDay(now).AddDate(0, 0, days+1))
+}
+
+// Deferred returns whether work on the task can't start yet.
+func (t Task) Deferred(now time.Time) bool {
+	return !t.Start.IsZero() && t.Start.After(now)
+}
+
+// RecurrenceAnchor returns the date the next instance of a recurring task is
+// scheduled from: its due date, or its start date if it has none. Zero if the
+// task has neither.
+func (t Task) RecurrenceAnchor() time.Time {
+	if t.Due.IsZero() {
+		return t.Start
+	}
+	return t.Due
 }
@@ -7,22 +7,32 @@ import (
 )
 
 // MarkBlocker marks one task (blocker) as a prerequisite for another task (blocked).
+// Returns a *CycleError if the blocker would end up transitively blocking itself,
+// including through blockers inherited from ancestors.
 func (tree *TaskTree) MarkBlocker(blockerId task.Id, blockedId task.Id) error {
 	tree.rwMu.Lock()
 	defer tree.rwMu.Unlock()
 
-	// TODO: Add detection of circular blocking chains
-
 	if err := tree.assertTaskExists(blockerId); err != nil {
 		return err
 	}
 	if err := tree.assertTaskExists(blockedId); err != nil {
 		return err
 	}
+	if util.Contains(tree.blocks[blockerId], blockedId) {
+		return fmt.Errorf("task %v already blocks %v", tree.label(blockerId), tree.label(blockedId))
+	}
+
+	// The blocked task's subtasks inherit the new blocker too, so the blocker
+	// must not (transitively) wait on any of them.
+	if err := tree.checkBlockerCycle(blockerId, tree.subtreeIds(blockedId)); err != nil {
+		return err
+	}
 
-	tree.blocks[blockerId] = append(tree.blocks[blockerId], blockedId)
-	tree.blockedBy[blockedId] = append(tree.blockedBy[blockedId], blockerId)
-	return nil
+	return tree.mutate("mark blocker", func() error {
+		tree.addBlock(blockerId, blockedId)
+		return nil
+	})
 }
 
 // UnmarkBlocker marks one task (blocker) as a no longer being a prerequisite for another task (blocked).
@@ -37,14 +47,14 @@ func (tree *TaskTree) UnmarkBlocker(blockerId task.Id, blockedId task.Id) error
 		return err
 	}
 
-	if blocks, exists := tree.blocks[blockerId]; exists {
-		tree.blocks[blockerId] = util.Remove(blocks, blockedId)
-	}
-	if blockedBy, exists := tree.blockedBy[blockedId]; exists {
-		tree.blocks[blockerId] = util.Remove(blockedBy, blockerId)
+	if !util.Contains(tree.blocks[blockerId], blockedId) {
+		return fmt.Errorf("task %v does not block %v", tree.label(blockerId), tree.label(blockedId))
 	}
 
-	return nil
+	return tree.mutate("unmark blocker", func() error {
+		tree.removeBlock(blockerId, blockedId)
+		return nil
+	})
 }
 
 // GetDirectBlockers gets tasks that are directly blocking the specific task.
@@ -67,57 +77,90 @@ func (tree *TaskTree) GetDirectBlockers(id task.Id) ([]task.Task, error) {
 // GetAllBlockers gets all tasks that either:
 //   - directly block a task
 //   - block a task's ancestors
+//
+// Blockers are ordered so that each comes after the other blockers it
+// (transitively) waits on, see TopologicalOrder.
 func (tree *TaskTree) GetAllBlockers(id task.Id) ([]task.Task, error) {
 	tree.rwMu.RLock()
 	defer tree.rwMu.RUnlock()
 
-	// TODO: Ensure blockers are returned in a logical order (toposort?)
-
 	if err := tree.assertTaskExists(id); err != nil {
 		return nil, err
 	}
 
-	seenBlockers := make(map[task.Id]struct{})
-
-	blockers, err := tree.GetDirectBlockers(id)
-	if err != nil {
-		return nil, fmt.Errorf("could not get direct blockers of task %v", id)
+	blockerIds := make([]task.Id, 0)
+	for _, blockerId := range tree.waitsOn(id) {
+		if !util.Contains(blockerIds, blockerId) {
+			blockerIds = append(blockerIds, blockerId)
+		}
 	}
-	for _, blocker := range blockers {
-		seenBlockers[blocker.Id] = struct{}{}
+	if len(blockerIds) > 1 {
+		blockerIds = tree.toposort(blockerIds, func(blockerId task.Id) []task.Id {
+			return util.Filter(blockerIds, func(otherId task.Id) bool {
+				return otherId != blockerId && tree.findWaitPath(blockerId, []task.Id{otherId}) != nil
+			})
+		})
 	}
 
-	ancestors, err := tree.GetAncestorTasks(id)
-	if err != nil {
-		return nil, err
-	}
+	return tree.idsToTasks(blockerIds), nil
+}
 
-	for _, ancestor := range ancestors {
-		ancestorBlockers, err := tree.GetDirectBlockers(ancestor.Id)
-		if err != nil {
-			return nil, err
-		}
+// IsBlocked checks if a task or any of its parent tasks are blocked.
+// Only incomplete blockers count as blocking.
+func (tree *TaskTree) IsBlocked(id task.Id) (bool, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
 
-		for _, ancestorBlocker := range ancestorBlockers {
-			if _, exists := seenBlockers[ancestorBlocker.Id]; !exists {
-				blockers = append(blockers, ancestorBlocker)
-				seenBlockers[ancestorBlocker.Id] = struct{}{}
-			}
-		}
+	blockers, err := tree.GetAllBlockers(id)
+	if err != nil {
+		return false, err
 	}
 
-	return blockers, nil
+	return len(incomplete(blockers)) != 0, nil
 }
 
-// IsBlocked checks if a task or any of its parent tasks are blocked.
-func (tree *TaskTree) IsBlocked(id task.Id) (bool, error) {
+// IsDirectlyBlocked checks if a task itself is blocked, ignoring blockers of its parent tasks.
+// Only incomplete blockers count as blocking.
+func (tree *TaskTree) IsDirectlyBlocked(id task.Id) (bool, error) {
 	tree.rwMu.RLock()
 	defer tree.rwMu.RUnlock()
 
-	blockers, err := tree.GetAllBlockers(id)
+	blockers, err := tree.GetDirectBlockers(id)
 	if err != nil {
 		return false, err
 	}
 
-	return len(blockers) != 0, nil
+	return len(incomplete(blockers)) != 0, nil
+}
+
+// GetBlockedTasks returns the blocked tasks in a single pass over the tree:
+// those that are blocked themselves, as by IsDirectlyBlocked, and those that
+// are only blocked through one of their ancestors. A task is blocked, as by
+// IsBlocked, if it is in either set. Cheaper than checking every task.
+func (tree *TaskTree) GetBlockedTasks() (directly map[task.Id]bool, byAncestor map[task.Id]bool) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	directly = make(map[task.Id]bool)
+	byAncestor = make(map[task.Id]bool)
+	var visit func(id task.Id, ancestorBlocked bool)
+	visit = func(id task.Id, ancestorBlocked bool) {
+		blocked := len(incomplete(tree.idsToTasks(tree.blockedBy[id]))) != 0
+		if blocked {
+			directly[id] = true
+		} else if ancestorBlocked {
+			byAncestor[id] = true
+		}
+		for _, subtaskId := range tree.subtasks[id] {
+			visit(subtaskId, blocked || ancestorBlocked)
+		}
+	}
+	for _, rootId := range tree.roots {
+		visit(rootId, false)
+	}
+	return directly, byAncestor
+}
+
+func incomplete(tasks []task.Task) []task.Task {
+	return util.Filter(tasks, func(t task.Task) bool { return !t.Completed })
 }
@@ -0,0 +1,30 @@
+package tasktree
+
+import "testing"
+
+func TestGetBlockedTasks(t *testing.T) {
+	// a is blocked by b, which is complete, and a1 by c, so a11 is only blocked
+	// through its parent. d1 inherits the blocker of d.
+	tree, ids := testTreeSpec{
+		tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"a2", "a"}, {"b", ""}, {"c", ""}, {"d", ""}, {"d1", "d"}},
+		blockers: [][2]string{{"b", "a"}, {"c", "a1"}, {"c", "d"}},
+	}.build(t)
+	if _, _, err := tree.CompleteTask(ids["b"]); err != nil {
+		t.Fatal(err)
+	}
+
+	directly, byAncestor := tree.GetBlockedTasks()
+	for name, id := range ids {
+		wantDirectly, _ := tree.IsDirectlyBlocked(id)
+		wantBlocked, _ := tree.IsBlocked(id)
+		if directly[id] != wantDirectly {
+			t.Errorf("%v: got directly blocked %v, want %v", name, directly[id], wantDirectly)
+		}
+		if byAncestor[id] != (wantBlocked && !wantDirectly) {
+			t.Errorf("%v: got blocked by ancestor %v, want %v", name, byAncestor[id], wantBlocked && !wantDirectly)
+		}
+	}
+	if !directly[ids["a1"]] || !byAncestor[ids["a11"]] || !byAncestor[ids["d1"]] || directly[ids["a"]] {
+		t.Error("expected a1 to be directly blocked and a11 and d1 to be blocked by an ancestor, but not a")
+	}
+}
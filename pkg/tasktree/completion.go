@@ -0,0 +1,203 @@
+package tasktree
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A CompletionPolicy decides how completing or reopening a task affects its
+// parent and subtasks. With every policy but Independent, reopening a task
+// also reopens its completed ancestors, since they can't be done while one of
+// their subtasks isn't.
+type CompletionPolicy int
+
+const (
+	// Independent completes and reopens tasks without affecting any other task.
+	Independent CompletionPolicy = iota
+	// AutoCompleteParent completes a parent task once all of its subtasks are
+	// completed, repeating up the tree.
+	AutoCompleteParent
+	// CascadeDown completes every descendant of a completed task.
+	CascadeDown
+	// RequireSubtasksComplete refuses to complete a task that has open descendants.
+	RequireSubtasksComplete
+)
+
+func (p CompletionPolicy) String() string {
+	switch p {
+	case Independent:
+		return "independent"
+	case AutoCompleteParent:
+		return "auto-complete-parent"
+	case CascadeDown:
+		return "cascade-down"
+	case RequireSubtasksComplete:
+		return "require-subtasks-complete"
+	default:
+		return fmt.Sprintf("CompletionPolicy(%d)", int(p))
+	}
+}
+
+// ParseCompletionPolicy parses a CompletionPolicy from its name (e.g. "cascade-down").
+func ParseCompletionPolicy(name string) (CompletionPolicy, error) {
+	for p := Independent; p <= RequireSubtasksComplete; p++ {
+		if strings.EqualFold(name, p.String()) {
+			return p, nil
+		}
+	}
+	return Independent, fmt.Errorf("unknown completion policy %q, expected one of: independent, auto-complete-parent, cascade-down, require-subtasks-complete", name)
+}
+
+// An OpenSubtasksError is returned when completing a task is refused by the
+// RequireSubtasksComplete policy.
+type OpenSubtasksError struct {
+	Id   task.Id
+	Open []task.Task
+}
+
+func (e *OpenSubtasksError) Error() string {
+	aliases := make([]string, len(e.Open))
+	for i, t := range e.Open {
+		aliases[i] = t.Alias
+	}
+	return fmt.Sprintf("task has %d open subtasks: %v", len(e.Open), strings.Join(aliases, ", "))
+}
+
+// CompletionPolicy returns the policy applied by CompleteTask and ReopenTask.
+func (tree *TaskTree) CompletionPolicy() CompletionPolicy {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+	return tree.completionPolicy
+}
+
+// SetCompletionPolicy sets the policy applied by CompleteTask and ReopenTask.
+// The policy is saved along with the TaskTree.
+func (tree *TaskTree) SetCompletionPolicy(policy CompletionPolicy) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if policy < Independent || policy > RequireSubtasksComplete {
+		return fmt.Errorf("unknown completion policy %v", policy)
+	}
+
+	return tree.mutate("set completion policy", func() error {
+		tree.setCompletionPolicy(policy)
+		return nil
+	})
+}
+
+// CompleteTask marks a task as completed, applying the completion policy.
+// Returns every task whose completion changed, starting with the given task.
+// Completing a recurring task creates its next instance, see task.Recurrence.
+// The next instances are returned as created.
+func (tree *TaskTree) CompleteTask(id task.Id) (changed []task.Task, created []task.Task, err error) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return nil, nil, err
+	}
+
+	if tree.completionPolicy == RequireSubtasksComplete {
+		open := incomplete(tree.idsToTasks(tree.subtreeIds(id)[1:]))
+		if len(open) > 0 {
+			return nil, nil, &OpenSubtasksError{Id: id, Open: open}
+		}
+	}
+
+	changed = make([]task.Task, 0)
+	created = make([]task.Task, 0)
+	err = tree.mutate("complete task", func() error {
+		changed = append(changed, tree.setCompleted(id, true)...)
+
+		switch tree.completionPolicy {
+		case AutoCompleteParent:
+			for _, ancestorId := range tree.ancestorIds(id) {
+				if len(incomplete(tree.idsToTasks(tree.subtasks[ancestorId]))) > 0 {
+					break
+				}
+				changed = append(changed, tree.setCompleted(ancestorId, true)...)
+			}
+		case CascadeDown:
+			for _, descendantId := range tree.subtreeIds(id)[1:] {
+				changed = append(changed, tree.setCompleted(descendantId, true)...)
+			}
+		}
+
+		// Ancestors recur first so that the subtrees they copy don't include
+		// the next instances of their descendants.
+		recurring := util.Filter(changed, func(t task.Task) bool { return !t.Recurrence.IsZero() })
+		slices.SortStableFunc(recurring, func(a, b task.Task) int {
+			return cmp.Compare(len(tree.ancestorIds(a.Id)), len(tree.ancestorIds(b.Id)))
+		})
+		now := time.Now()
+		cloned := make(map[task.Id]bool)
+		for _, t := range recurring {
+			// Tasks copied along with the subtree of a recurring ancestor
+			// already have their next instance.
+			if cloned[t.Id] {
+				continue
+			}
+			created = append(created, tree.recur(t.Id, now))
+			if t.Recurrence.Subtree {
+				for _, descendantId := range tree.subtreeIds(t.Id) {
+					cloned[descendantId] = true
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return changed, created, nil
+}
+
+// ReopenTask marks a task as not completed, applying the completion policy.
+// Returns every task whose completion changed, starting with the given task.
+func (tree *TaskTree) ReopenTask(id task.Id) ([]task.Task, error) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return nil, err
+	}
+
+	changed := make([]task.Task, 0)
+	err := tree.mutate("reopen task", func() error {
+		changed = append(changed, tree.setCompleted(id, false)...)
+
+		if tree.completionPolicy != Independent {
+			for _, ancestorId := range tree.ancestorIds(id) {
+				changed = append(changed, tree.setCompleted(ancestorId, false)...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// setCompleted sets whether a task is completed and when, returning the
+// updated task if it changed. The caller must hold the write lock and be
+// inside of mutate.
+func (tree *TaskTree) setCompleted(id task.Id, completed bool) []task.Task {
+	t := tree.tasks[id]
+	if t.Completed == completed {
+		return nil
+	}
+	t.Completed = completed
+	t.CompletedAt = time.Time{}
+	if completed {
+		t.CompletedAt = time.Now()
+	}
+	tree.setTask(t)
+	return []task.Task{t}
+}
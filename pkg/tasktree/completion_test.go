@@ -0,0 +1,131 @@
+package tasktree
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"testing"
+)
+
+// A completionCase completes or reopens a task of the tree
+// a{a1{a11, a12}, a2}, b under a completion policy.
+type completionCase struct {
+	name string
+	// completed are the tasks completed before the policy is set.
+	completed []string
+	reopen    bool
+	target    string
+
+	// wantChanged are the tasks whose completion changed, in order.
+	wantChanged []string
+	// wantOpen are the open subtasks of an OpenSubtasksError, if one is
+	// expected.
+	wantOpen []string
+}
+
+// completedNames returns the sorted names of the completed tasks of a tree.
+func completedNames(tree *TaskTree) []string {
+	var res []string
+	for _, t := range tree.GetAllTasks() {
+		if t.Completed {
+			res = append(res, t.Name)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// testCompletionPolicy runs completion cases under a policy. Each case is
+// undone and redone, which must restore the completion of every task.
+func testCompletionPolicy(t *testing.T, policy CompletionPolicy, cases []completionCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tree, ids := testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"a12", "a1"}, {"a2", "a"}, {"b", ""}}}.build(t)
+			for _, name := range c.completed {
+				if _, _, err := tree.CompleteTask(ids[name]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tree.SetCompletionPolicy(policy); err != nil {
+				t.Fatal(err)
+			}
+			before := completedNames(tree)
+
+			var changed []task.Task
+			var err error
+			if c.reopen {
+				changed, err = tree.ReopenTask(ids[c.target])
+			} else {
+				changed, _, err = tree.CompleteTask(ids[c.target])
+			}
+
+			if c.wantOpen != nil {
+				var openErr *OpenSubtasksError
+				if !errors.As(err, &openErr) {
+					t.Fatalf("got error %v, want an OpenSubtasksError", err)
+				}
+				if got := util.Map(openErr.Open, func(t task.Task) string { return t.Name }); !slices.Equal(got, c.wantOpen) {
+					t.Errorf("got open subtasks %v, want %v", got, c.wantOpen)
+				}
+				if got := completedNames(tree); !slices.Equal(got, before) {
+					t.Errorf("got completed tasks %v after a refused completion, want %v", got, before)
+				}
+				if name, _ := tree.Undo(); name != "set completion policy" {
+					t.Errorf("a refused completion was recorded in the history as %q", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := util.Map(changed, func(t task.Task) string { return t.Name }); !slices.Equal(got, c.wantChanged) {
+				t.Errorf("got changed tasks %v, want %v", got, c.wantChanged)
+			}
+			after := completedNames(tree)
+
+			if _, err := tree.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := completedNames(tree); !slices.Equal(got, before) {
+				t.Errorf("got completed tasks %v after undo, want %v", got, before)
+			}
+			if _, err := tree.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := completedNames(tree); !slices.Equal(got, after) {
+				t.Errorf("got completed tasks %v after redo, want %v", got, after)
+			}
+		})
+	}
+}
+
+func TestAutoCompleteParent(t *testing.T) {
+	testCompletionPolicy(t, AutoCompleteParent, []completionCase{
+		{name: "open siblings", target: "a11", wantChanged: []string{"a11"}},
+		{name: "last subtask", completed: []string{"a12"}, target: "a11", wantChanged: []string{"a11", "a1"}},
+		{name: "up to the root", completed: []string{"a12", "a2"}, target: "a11", wantChanged: []string{"a11", "a1", "a"}},
+		{name: "parent with open subtasks", target: "a", wantChanged: []string{"a"}},
+		{name: "reopen", completed: []string{"a", "a1", "a11", "a12", "a2"}, reopen: true, target: "a11", wantChanged: []string{"a11", "a1", "a"}},
+	})
+}
+
+func TestRequireSubtasksComplete(t *testing.T) {
+	testCompletionPolicy(t, RequireSubtasksComplete, []completionCase{
+		{name: "leaf", target: "a11", wantChanged: []string{"a11"}},
+		{name: "open subtasks", target: "a1", wantOpen: []string{"a11", "a12"}},
+		{name: "open descendants", completed: []string{"a11", "a2"}, target: "a", wantOpen: []string{"a1", "a12"}},
+		{name: "completed subtasks", completed: []string{"a11", "a12"}, target: "a1", wantChanged: []string{"a1"}},
+		{name: "reopen", completed: []string{"a", "a1", "a11", "a12", "a2"}, reopen: true, target: "a11", wantChanged: []string{"a11", "a1", "a"}},
+	})
+}
+
+func TestCascadeDown(t *testing.T) {
+	testCompletionPolicy(t, CascadeDown, []completionCase{
+		{name: "leaf", target: "a11", wantChanged: []string{"a11"}},
+		{name: "subtree", target: "a", wantChanged: []string{"a", "a1", "a11", "a12", "a2"}},
+		{name: "partly completed subtree", completed: []string{"a12"}, target: "a", wantChanged: []string{"a", "a1", "a11", "a2"}},
+		{name: "reopen", completed: []string{"a", "a1", "a11", "a12", "a2"}, reopen: true, target: "a1", wantChanged: []string{"a1", "a"}},
+	})
+}
@@ -0,0 +1,161 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"time"
+)
+
+// A ScheduledTask is a task of a CriticalPath along with when it can be worked
+// on. Times are estimated durations from now, assuming unlimited people can
+// work on tasks in parallel.
+type ScheduledTask struct {
+	Task task.Task
+	// Work is the estimated time left on the task and its descendants.
+	Work time.Duration
+
+	EarliestStart  time.Duration
+	EarliestFinish time.Duration
+	LatestStart    time.Duration
+	LatestFinish   time.Duration
+	// Slack is how long the task can be delayed without delaying the goal.
+	Slack time.Duration
+}
+
+// Critical returns whether delaying the task delays the goal.
+func (s ScheduledTask) Critical() bool {
+	return s.Slack == 0
+}
+
+// A CriticalPath schedules the work needed to finish a goal task.
+type CriticalPath struct {
+	Goal task.Task
+	// Duration is the estimated time until the goal can be finished.
+	Duration time.Duration
+	// Tasks contains the goal and every open task it transitively waits on,
+	// ordered so that every task comes after the tasks it waits on.
+	Tasks []ScheduledTask
+	// Chain is the sequence of critical tasks that determines Duration,
+	// ending with the goal.
+	Chain []task.Task
+}
+
+// GetCriticalPath schedules the work needed to finish a goal task and finds
+// the chain of tasks that determines how long it takes. Every task is treated
+// as a single unit of work made of the remaining estimated time of itself and
+// its descendants (see Rollup), which waits on the open blockers of any task
+// in its subtree.
+func (tree *TaskTree) GetCriticalPath(goalId task.Id) (CriticalPath, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if err := tree.assertTaskExists(goalId); err != nil {
+		return CriticalPath{}, err
+	}
+
+	// Collect the goal and everything it transitively waits on.
+	dependencies := make(map[task.Id][]task.Id)
+	var visit func(id task.Id)
+	visit = func(id task.Id) {
+		if _, visited := dependencies[id]; visited {
+			return
+		}
+		dependencies[id] = tree.subtreeBlockers(id)
+		for _, dependencyId := range dependencies[id] {
+			visit(dependencyId)
+		}
+	}
+	visit(goalId)
+
+	// Tasks that could come in either order are ordered depth-first.
+	ids := make([]task.Id, 0, len(dependencies))
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			if _, ok := dependencies[id]; ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	order := tree.toposort(ids, func(id task.Id) []task.Id { return dependencies[id] })
+	if len(order) < len(ids) {
+		return CriticalPath{}, fmt.Errorf("the tasks blocking task %v block each other through their subtasks", tree.label(goalId))
+	}
+
+	// Forward pass: a task can start once everything it waits on is finished.
+	scheduled := make(map[task.Id]*ScheduledTask, len(order))
+	for _, id := range order {
+		s := &ScheduledTask{Task: tree.tasks[id], Work: tree.rollup(id).Remaining}
+		for _, dependencyId := range dependencies[id] {
+			s.EarliestStart = max(s.EarliestStart, scheduled[dependencyId].EarliestFinish)
+		}
+		s.EarliestFinish = s.EarliestStart + s.Work
+		scheduled[id] = s
+	}
+
+	// Backward pass: a task must finish before any task waiting on it has to start.
+	duration := scheduled[goalId].EarliestFinish
+	for _, s := range scheduled {
+		s.LatestFinish = duration
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		s := scheduled[order[i]]
+		s.LatestStart = s.LatestFinish - s.Work
+		s.Slack = s.LatestStart - s.EarliestStart
+		for _, dependencyId := range dependencies[order[i]] {
+			scheduled[dependencyId].LatestFinish = min(scheduled[dependencyId].LatestFinish, s.LatestStart)
+		}
+	}
+
+	path := CriticalPath{
+		Goal:     tree.tasks[goalId],
+		Duration: duration,
+		Tasks:    make([]ScheduledTask, len(order)),
+	}
+	for i, id := range order {
+		path.Tasks[i] = *scheduled[id]
+	}
+
+	// Walk back from the goal through the tasks that finish right as the
+	// current one can start.
+	chain := []task.Task{tree.tasks[goalId]}
+	for curr := scheduled[goalId]; ; {
+		var next *ScheduledTask
+		for _, dependencyId := range dependencies[curr.Task.Id] {
+			if s := scheduled[dependencyId]; s.Critical() && s.EarliestFinish == curr.EarliestStart {
+				next = s
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		chain = append([]task.Task{next.Task}, chain...)
+		curr = next
+	}
+	path.Chain = chain
+
+	return path, nil
+}
+
+// subtreeBlockers returns the open tasks outside of the subtree of a task that
+// the task or any of its descendants wait on. The caller must hold the lock.
+func (tree *TaskTree) subtreeBlockers(id task.Id) []task.Id {
+	subtree := tree.subtreeIds(id)
+	inSubtree := make(map[task.Id]bool, len(subtree))
+	for _, descendantId := range subtree {
+		inSubtree[descendantId] = true
+	}
+
+	seen := make(map[task.Id]bool)
+	res := make([]task.Id, 0)
+	for _, descendantId := range subtree {
+		for _, blockerId := range tree.openBlockers(descendantId) {
+			if !inSubtree[blockerId] && !seen[blockerId] {
+				seen[blockerId] = true
+				res = append(res, blockerId)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetCriticalPath(t *testing.T) {
+	// A diamond: s blocks l and r, which both block the goal g. x is left out
+	// of the path.
+	diamond := testTreeSpec{
+		tasks:    [][2]string{{"s", ""}, {"l", ""}, {"r", ""}, {"g", ""}, {"x", ""}},
+		blockers: [][2]string{{"s", "l"}, {"s", "r"}, {"l", "g"}, {"r", "g"}},
+	}
+	h := time.Hour
+	tests := []struct {
+		name      string
+		estimates map[string]time.Duration
+		completed []string
+
+		wantDuration time.Duration
+		// wantStart and wantSlack are the earliest start and the slack of
+		// every task on the path.
+		wantStart map[string]time.Duration
+		wantSlack map[string]time.Duration
+		wantChain []string
+	}{
+		{
+			name:         "longer branch",
+			estimates:    map[string]time.Duration{"s": h, "l": 2 * h, "r": 5 * h, "g": h},
+			wantDuration: 7 * h,
+			wantStart:    map[string]time.Duration{"s": 0, "l": h, "r": h, "g": 6 * h},
+			wantSlack:    map[string]time.Duration{"s": 0, "l": 3 * h, "r": 0, "g": 0},
+			wantChain:    []string{"s", "r", "g"},
+		},
+		{
+			name:         "zero estimate branch",
+			estimates:    map[string]time.Duration{"s": h, "l": 2 * h, "g": h},
+			wantDuration: 4 * h,
+			wantStart:    map[string]time.Duration{"s": 0, "l": h, "r": h, "g": 3 * h},
+			wantSlack:    map[string]time.Duration{"s": 0, "l": 0, "r": 2 * h, "g": 0},
+			wantChain:    []string{"s", "l", "g"},
+		},
+		{
+			// Every task is critical, and the chain goes through the first
+			// blocker of each task.
+			name:         "zero estimates",
+			wantDuration: 0,
+			wantStart:    map[string]time.Duration{"s": 0, "l": 0, "r": 0, "g": 0},
+			wantSlack:    map[string]time.Duration{"s": 0, "l": 0, "r": 0, "g": 0},
+			wantChain:    []string{"s", "l", "g"},
+		},
+		{
+			name:         "completed blocker",
+			estimates:    map[string]time.Duration{"s": h, "l": 2 * h, "r": 5 * h, "g": h},
+			completed:    []string{"s"},
+			wantDuration: 6 * h,
+			wantStart:    map[string]time.Duration{"l": 0, "r": 0, "g": 5 * h},
+			wantSlack:    map[string]time.Duration{"l": 3 * h, "r": 0, "g": 0},
+			wantChain:    []string{"r", "g"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree, ids := diamond.build(t)
+			for name, estimate := range test.estimates {
+				tsk, _ := tree.GetTask(ids[name])
+				tsk.EstimatedTime = estimate
+				if err := tree.UpdateTask(tsk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range test.completed {
+				if _, _, err := tree.CompleteTask(ids[name]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			path, err := tree.GetCriticalPath(ids["g"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path.Duration != test.wantDuration {
+				t.Errorf("got duration %v, want %v", path.Duration, test.wantDuration)
+			}
+			if len(path.Tasks) != len(test.wantStart) {
+				t.Errorf("got %v tasks, want %v", len(path.Tasks), len(test.wantStart))
+			}
+			for _, s := range path.Tasks {
+				if want, ok := test.wantStart[s.Task.Name]; !ok || s.EarliestStart != want {
+					t.Errorf("%v: got earliest start %v, want %v", s.Task.Name, s.EarliestStart, want)
+				}
+				if want := test.wantSlack[s.Task.Name]; s.Slack != want {
+					t.Errorf("%v: got slack %v, want %v", s.Task.Name, s.Slack, want)
+				}
+			}
+			if got := util.Map(path.Chain, func(t task.Task) string { return t.Name }); !slices.Equal(got, test.wantChain) {
+				t.Errorf("got chain %v, want %v", got, test.wantChain)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+)
+
+// A CycleKind identifies the relationship that would form a cycle.
+type CycleKind int
+
+const (
+	// SubtaskCycle is a cycle in the subtask hierarchy (a task would become its own ancestor).
+	SubtaskCycle CycleKind = iota
+	// BlockerCycle is a cycle in the blocker graph (a task would transitively block itself,
+	// either directly or through the blockers it inherits from its ancestors).
+	BlockerCycle
+)
+
+// A CycleError is returned when an operation would introduce a cycle into the
+// TaskTree.
+type CycleError struct {
+	Kind CycleKind
+	// Path is the chain of tasks forming the cycle. It starts and ends with the same task.
+	// For a SubtaskCycle each task is the parent of the next one, for a BlockerCycle
+	// each task blocks the next one.
+	Path []task.Id
+	// Aliases are the aliases of the tasks in Path, used in the error message if set.
+	Aliases []string
+}
+
+func (e *CycleError) Error() string {
+	labels := e.Aliases
+	if len(labels) != len(e.Path) {
+		labels = util.Map(e.Path, func(id task.Id) string { return string(id) })
+	}
+	path := strings.Join(labels, " → ")
+	switch e.Kind {
+	case SubtaskCycle:
+		return fmt.Sprintf("subtask cycle: %v", path)
+	default:
+		return fmt.Sprintf("blocker cycle: %v", path)
+	}
+}
+
+// cycleError creates a CycleError, filling in the aliases of the tasks in the
+// path. The caller must hold the lock.
+func (tree *TaskTree) cycleError(kind CycleKind, path []task.Id) *CycleError {
+	return &CycleError{Kind: kind, Path: path, Aliases: util.Map(path, tree.label)}
+}
+
+// ancestorIds returns the ids of the ancestors of a task in order
+// (parent, grandparent, etc.). The caller must hold the lock.
+func (tree *TaskTree) ancestorIds(id task.Id) []task.Id {
+	res := make([]task.Id, 0)
+	for {
+		parentId, exists := tree.subtaskOf[id]
+		if !exists {
+			return res
+		}
+		res = append(res, parentId)
+		id = parentId
+	}
+}
+
+// subtreeIds returns the ids of a task and all of its descendants in depth-first order.
+// The caller must hold the lock.
+func (tree *TaskTree) subtreeIds(id task.Id) []task.Id {
+	res := []task.Id{id}
+	for _, subtaskId := range tree.subtasks[id] {
+		res = append(res, tree.subtreeIds(subtaskId)...)
+	}
+	return res
+}
+
+// waitsOn returns the ids of the tasks that a task has to wait for, i.e. its
+// direct blockers and the blockers of its ancestors. The caller must hold the lock.
+func (tree *TaskTree) waitsOn(id task.Id) []task.Id {
+	res := append([]task.Id{}, tree.blockedBy[id]...)
+	for _, ancestorId := range tree.ancestorIds(id) {
+		res = append(res, tree.blockedBy[ancestorId]...)
+	}
+	return res
+}
+
+// findWaitPath searches the blocker graph (including blockers inherited from
+// ancestors) for a chain of tasks starting at from where each task waits on the
+// next and ending at any of the targets. Returns nil if there is no such chain.
+// The caller must hold the lock.
+func (tree *TaskTree) findWaitPath(from task.Id, targets []task.Id) []task.Id {
+	isTarget := make(map[task.Id]bool, len(targets))
+	for _, target := range targets {
+		isTarget[target] = true
+	}
+
+	prev := map[task.Id]task.Id{from: from}
+	queue := []task.Id{from}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+
+		if isTarget[curr] {
+			path := []task.Id{curr}
+			for curr != from {
+				curr = prev[curr]
+				path = append([]task.Id{curr}, path...)
+			}
+			return path
+		}
+
+		for _, next := range tree.waitsOn(curr) {
+			if _, seen := prev[next]; !seen {
+				prev[next] = curr
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBlockerCycle checks whether making blockerId block every task in blocked
+// would create a blocker cycle. The caller must hold the lock.
+func (tree *TaskTree) checkBlockerCycle(blockerId task.Id, blocked []task.Id) error {
+	waitPath := tree.findWaitPath(blockerId, blocked)
+	if waitPath == nil {
+		return nil
+	}
+
+	// waitPath goes from the blocker to a newly blocked task along "waits on"
+	// edges, reverse it to get a chain of "blocks" edges and close the cycle.
+	path := make([]task.Id, 0, len(waitPath)+1)
+	for i := len(waitPath) - 1; i >= 0; i-- {
+		path = append(path, waitPath[i])
+	}
+	path = append(path, waitPath[len(waitPath)-1])
+	return tree.cycleError(BlockerCycle, path)
+}
+
+// checkSubtaskCycle checks whether making subtaskId a subtask of parentId would
+// create a cycle in the subtask hierarchy, or a blocker cycle through the blockers
+// the subtask would inherit from its new ancestors. The caller must hold the lock.
+func (tree *TaskTree) checkSubtaskCycle(parentId task.Id, subtaskId task.Id) error {
+	if parentId == subtaskId {
+		return tree.cycleError(SubtaskCycle, []task.Id{parentId, parentId})
+	}
+
+	ancestors := tree.ancestorIds(parentId)
+	for i, ancestorId := range ancestors {
+		if ancestorId != subtaskId {
+			continue
+		}
+
+		path := []task.Id{subtaskId}
+		for j := i - 1; j >= 0; j-- {
+			path = append(path, ancestors[j])
+		}
+		path = append(path, parentId, subtaskId)
+		return tree.cycleError(SubtaskCycle, path)
+	}
+
+	subtree := tree.subtreeIds(subtaskId)
+	for _, inheritedBlockerId := range tree.waitsOn(parentId) {
+		if err := tree.checkBlockerCycle(inheritedBlockerId, subtree); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package tasktree
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCycleErrors(t *testing.T) {
+	// a
+	//   a1
+	//     a11
+	// b
+	//   b1
+	// c
+	hierarchy := [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"b", ""}, {"b1", "b"}, {"c", ""}}
+
+	tests := []struct {
+		name     string
+		blockers [][2]string
+		op       func(tree *TaskTree, ids map[string]task.Id) error
+		wantKind CycleKind
+		wantPath []string // nil if the operation succeeds
+	}{
+		{
+			name:     "task blocking itself",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["c"], ids["c"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"c", "c"},
+		},
+		{
+			name:     "task blocking its parent",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["a1"], ids["a"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"a1", "a1"},
+		},
+		{
+			name:     "task blocking an ancestor",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["a11"], ids["a"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"a11", "a11"},
+		},
+		{
+			name: "task blocking a descendant",
+			op:   func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["a"], ids["a11"]) },
+		},
+		{
+			name:     "direct cycle",
+			blockers: [][2]string{{"b", "c"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["c"], ids["b"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"b", "c", "b"},
+		},
+		{
+			name:     "transitive cycle",
+			blockers: [][2]string{{"a", "b"}, {"b", "c"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["c"], ids["a"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"a", "b", "c", "a"},
+		},
+		{
+			name:     "cycle through a blocker inherited by the blocker",
+			blockers: [][2]string{{"c", "a"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["a11"], ids["c"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"c", "a11", "c"},
+		},
+		{
+			name:     "cycle through a blocker inherited by a subtask of the blocked task",
+			blockers: [][2]string{{"b1", "c"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["c"], ids["b"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"b1", "c", "b1"},
+		},
+		{
+			name:     "indirect cycle through inherited blockers",
+			blockers: [][2]string{{"c", "a"}, {"a11", "b"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["b1"], ids["c"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"c", "a11", "b1", "c"},
+		},
+		{
+			name:     "cycle between blockers inherited both ways",
+			blockers: [][2]string{{"b1", "a"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["a1"], ids["b"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"b1", "a1", "b1"},
+		},
+		{
+			name:     "subtask of a blocked task blocking another task",
+			blockers: [][2]string{{"b1", "a"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkBlocker(ids["a1"], ids["c"]) },
+		},
+		{
+			name:     "task becoming its own subtask",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkSubtask(ids["c"], ids["c"]) },
+			wantKind: SubtaskCycle,
+			wantPath: []string{"c", "c"},
+		},
+		{
+			name:     "root becoming a subtask of its descendant",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MarkSubtask(ids["a11"], ids["a"]) },
+			wantKind: SubtaskCycle,
+			wantPath: []string{"a", "a1", "a11", "a"},
+		},
+		{
+			name:     "moving a task under its subtask",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a1"], ids["a11"], 0) },
+			wantKind: SubtaskCycle,
+			wantPath: []string{"a1", "a11", "a1"},
+		},
+		{
+			name:     "moving a task under itself",
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["b"], ids["b"], 0) },
+			wantKind: SubtaskCycle,
+			wantPath: []string{"b", "b"},
+		},
+		{
+			name:     "moving a blocker under the task it blocks",
+			blockers: [][2]string{{"c", "b"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["c"], ids["b1"], 0) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"c", "c"},
+		},
+		{
+			name:     "moving the blocker of a task under a blocked task",
+			blockers: [][2]string{{"c", "b"}, {"a11", "c"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a"], ids["b"], 0) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"a11", "c", "a11"},
+		},
+		{
+			name:     "indenting a blocker under the task it blocks",
+			blockers: [][2]string{{"b", "a"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.IndentTask(ids["b"]) },
+			wantKind: BlockerCycle,
+			wantPath: []string{"b", "b"},
+		},
+		{
+			name:     "moving a task away from an inherited blocker",
+			blockers: [][2]string{{"c", "a"}},
+			op:       func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a1"], ids["c"], 0) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, ids := testTreeSpec{tasks: hierarchy, blockers: tt.blockers}.build(t)
+			before := snapshot(tree)
+
+			err := tt.op(tree, ids)
+			if tt.wantPath == nil {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var cycleErr *CycleError
+			if !errors.As(err, &cycleErr) {
+				t.Fatalf("got error %v, want a CycleError", err)
+			}
+			if cycleErr.Kind != tt.wantKind {
+				t.Errorf("got kind %v, want %v", cycleErr.Kind, tt.wantKind)
+			}
+			if got := names(tree, cycleErr.Path); !slices.Equal(got, tt.wantPath) {
+				t.Errorf("got path %v, want %v", got, tt.wantPath)
+			}
+
+			aliases := util.Map(cycleErr.Path, func(id task.Id) string { return tree.tasks[id].Alias })
+			if !slices.Equal(cycleErr.Aliases, aliases) {
+				t.Errorf("got aliases %v, want %v", cycleErr.Aliases, aliases)
+			}
+			if !strings.Contains(err.Error(), strings.Join(aliases, " → ")) {
+				t.Errorf("error %q doesn't name the path %v", err, aliases)
+			}
+			assertState(t, tree, before, "after a cycle was rejected")
+		})
+	}
+}
+
+func TestWaitsOn(t *testing.T) {
+	tree, ids := testTreeSpec{
+		tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"b", ""}, {"c", ""}, {"d", ""}},
+		blockers: [][2]string{{"b", "a"}, {"c", "a1"}, {"d", "a11"}},
+	}.build(t)
+
+	tests := []struct {
+		task string
+		want []string
+	}{
+		{"a", []string{"b"}},
+		{"a1", []string{"c", "b"}},
+		{"a11", []string{"d", "c", "b"}},
+		{"b", nil},
+	}
+	for _, tt := range tests {
+		if got := names(tree, tree.waitsOn(ids[tt.task])); !slices.Equal(got, tt.want) {
+			t.Errorf("%v waits on %v, want %v", tt.task, got, tt.want)
+		}
+	}
+}
+
+func TestFindWaitPath(t *testing.T) {
+	// a blocks b1 through b, and b1 blocks c.
+	tree, ids := testTreeSpec{
+		tasks:    [][2]string{{"a", ""}, {"b", ""}, {"b1", "b"}, {"c", ""}, {"d", ""}},
+		blockers: [][2]string{{"a", "b"}, {"b1", "c"}},
+	}.build(t)
+
+	tests := []struct {
+		name    string
+		from    string
+		targets []string
+		want    []string
+	}{
+		{"from a target", "c", []string{"c"}, []string{"c"}},
+		{"direct blocker", "b", []string{"a"}, []string{"b", "a"}},
+		{"inherited blocker", "b1", []string{"a"}, []string{"b1", "a"}},
+		{"through an inherited blocker", "c", []string{"a"}, []string{"c", "b1", "a"}},
+		{"closest of several targets", "c", []string{"a", "b1"}, []string{"c", "b1"}},
+		{"parents don't wait on the blockers of subtasks", "b", []string{"b1", "c"}, nil},
+		{"blocked tasks aren't waited on", "a", []string{"b", "b1", "c"}, nil},
+		{"unrelated task", "d", []string{"a"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets := util.Map(tt.targets, func(name string) task.Id { return ids[name] })
+			path := tree.findWaitPath(ids[tt.from], targets)
+			if tt.want == nil {
+				if path != nil {
+					t.Errorf("got path %v, want none", names(tree, path))
+				}
+				return
+			}
+			if got := names(tree, path); !slices.Equal(got, tt.want) {
+				t.Errorf("got path %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
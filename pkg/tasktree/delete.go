@@ -0,0 +1,141 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"strings"
+)
+
+// A DeletePolicy decides what happens to the subtasks of a deleted task.
+type DeletePolicy int
+
+const (
+	// ReparentToGrandparent makes the subtasks take the deleted task's place
+	// among its siblings, in order.
+	ReparentToGrandparent DeletePolicy = iota
+	// PromoteChildren makes the subtasks root tasks, placed right after the
+	// root task the deleted task belonged to.
+	PromoteChildren
+	// Cascade deletes the subtasks too, along with all of their descendants.
+	Cascade
+)
+
+func (p DeletePolicy) String() string {
+	switch p {
+	case ReparentToGrandparent:
+		return "reparent"
+	case PromoteChildren:
+		return "promote"
+	case Cascade:
+		return "cascade"
+	default:
+		return fmt.Sprintf("DeletePolicy(%d)", int(p))
+	}
+}
+
+// ParseDeletePolicy parses a DeletePolicy from its name (e.g. "cascade").
+func ParseDeletePolicy(name string) (DeletePolicy, error) {
+	for p := ReparentToGrandparent; p <= Cascade; p++ {
+		if strings.EqualFold(name, p.String()) {
+			return p, nil
+		}
+	}
+	return ReparentToGrandparent, fmt.Errorf("unknown delete policy %q, expected one of: reparent, promote, cascade", name)
+}
+
+// DeleteTask deletes a task from the tree by id. Its subtasks become root
+// tasks, as with PromoteChildren.
+func (tree *TaskTree) DeleteTask(id task.Id) error {
+	return tree.DeleteTaskWithPolicy(id, PromoteChildren)
+}
+
+// DeleteSubtree deletes a task along with all of its descendants.
+func (tree *TaskTree) DeleteSubtree(id task.Id) error {
+	return tree.DeleteTaskWithPolicy(id, Cascade)
+}
+
+// DeleteTaskWithPolicy deletes a task from the tree by id, handling its
+// subtasks according to a DeletePolicy. Every blocker relationship involving
+// a deleted task is removed. Time logged on deleted tasks stays in the time
+// log so that it still counts in reports, and a timer running for one of them
+// is stopped.
+func (tree *TaskTree) DeleteTaskWithPolicy(id task.Id, policy DeletePolicy) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+
+	name := "delete task"
+	if policy == Cascade {
+		name = "delete subtree"
+	}
+
+	return tree.mutate(name, func() error {
+		switch policy {
+		case ReparentToGrandparent:
+			parentId, index := tree.detach(id)
+			for i, subtaskId := range slices.Clone(tree.subtasks[id]) {
+				tree.detach(subtaskId)
+				tree.attach(subtaskId, parentId, index+i)
+			}
+		case PromoteChildren:
+			rootId := id
+			if ancestors := tree.ancestorIds(id); len(ancestors) > 0 {
+				rootId = ancestors[len(ancestors)-1]
+			}
+			index := slices.Index(tree.roots, rootId)
+			if rootId != id {
+				// The subtasks go after the root task rather than in its place.
+				index++
+			}
+			tree.detach(id)
+			for i, subtaskId := range slices.Clone(tree.subtasks[id]) {
+				tree.detach(subtaskId)
+				tree.attach(subtaskId, "", index+i)
+			}
+		case Cascade:
+			// Descendants are removed before their parents so that every task
+			// is detached before being removed.
+			subtree := tree.subtreeIds(id)
+			for i := len(subtree) - 1; i > 0; i-- {
+				tree.detach(subtree[i])
+				tree.unlinkBlockers(subtree[i])
+				tree.removeTask(subtree[i])
+			}
+			tree.detach(id)
+		default:
+			return fmt.Errorf("unknown delete policy %v", policy)
+		}
+
+		tree.unlinkBlockers(id)
+		tree.removeTask(id)
+		tree.stopDeletedTimer(timerNow())
+		return nil
+	})
+}
+
+// unlinkBlockers removes every blocker relationship a task is part of, in
+// both directions. The caller must hold the write lock and be inside of mutate.
+func (tree *TaskTree) unlinkBlockers(id task.Id) {
+	for _, blockedId := range slices.Clone(tree.blocks[id]) {
+		tree.removeBlock(id, blockedId)
+	}
+	for _, blockerId := range slices.Clone(tree.blockedBy[id]) {
+		tree.removeBlock(blockerId, id)
+	}
+}
+
+// GetSubtree returns a task and all of its descendants in depth-first order.
+func (tree *TaskTree) GetSubtree(id task.Id) ([]task.Task, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return nil, err
+	}
+
+	return tree.idsToTasks(tree.subtreeIds(id)), nil
+}
@@ -0,0 +1,100 @@
+package tasktree
+
+import (
+	"encoding/json"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDeletePolicies(t *testing.T) {
+	// a
+	//   a1
+	//     a11
+	//   a2
+	// b
+	hierarchy := [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"a2", "a"}, {"b", ""}}
+
+	tests := []struct {
+		name   string
+		delete func(tree *TaskTree, id task.Id) error
+		task   string
+		want   string
+	}{
+		{"default", (*TaskTree).DeleteTask, "a1", "a\n  a2\na11\nb"},
+		{"default root", (*TaskTree).DeleteTask, "a", "a1\n  a11\na2\nb"},
+		{"reparent", withPolicy(ReparentToGrandparent), "a1", "a\n  a11\n  a2\nb"},
+		{"reparent root", withPolicy(ReparentToGrandparent), "a", "a1\n  a11\na2\nb"},
+		{"promote", withPolicy(PromoteChildren), "a1", "a\n  a2\na11\nb"},
+		{"promote root", withPolicy(PromoteChildren), "a", "a1\n  a11\na2\nb"},
+		{"cascade", withPolicy(Cascade), "a1", "a\n  a2\nb"},
+		{"cascade root", withPolicy(Cascade), "a", "b"},
+		{"subtree", (*TaskTree).DeleteSubtree, "a", "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, ids := testTreeSpec{tasks: hierarchy}.build(t)
+			if err := tt.delete(tree, ids[tt.task]); err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(tree, func(t task.Task) string { return t.Name }); got != tt.want {
+				t.Errorf("got tree:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func withPolicy(policy DeletePolicy) func(tree *TaskTree, id task.Id) error {
+	return func(tree *TaskTree, id task.Id) error {
+		return tree.DeleteTaskWithPolicy(id, policy)
+	}
+}
+
+func TestDeleteKeepsSessions(t *testing.T) {
+	tree, ids := testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}}}.build(t)
+	// The JSON format keeps whole seconds.
+	now := time.Now().Truncate(time.Second)
+	for _, err := range []error{
+		tree.LogTime(ids["a"], now.Add(-3*time.Hour), now.Add(-2*time.Hour)),
+		tree.LogTime(ids["b"], now.Add(-2*time.Hour), now.Add(-time.Hour)),
+		tree.LogTime(ids["a1"], now.Add(-time.Hour), now),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := tree.StartTimer(ids["a1"]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.DeleteSubtree(ids["a"]); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := tree.GetSessions()
+	taskIds := []task.Id{ids["a"], ids["b"], ids["a1"], ids["a1"]}
+	if got := util.Map(sessions, func(s Session) task.Id { return s.TaskId }); !slices.Equal(got, taskIds) {
+		t.Fatalf("got sessions of tasks %v, want %v", got, taskIds)
+	}
+	if _, running := tree.ActiveTimer(); running {
+		t.Error("the timer of a deleted task is still running")
+	}
+
+	// The sessions of deleted tasks survive saving and loading.
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewTaskTree()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatalf("failed to load a tree with sessions of deleted tasks: %v", err)
+	}
+	if got := loaded.GetSessions(); !slices.EqualFunc(got, sessions, func(a, b Session) bool {
+		return a.TaskId == b.TaskId && a.Start.Equal(b.Start) && a.End.Equal(b.End)
+	}) {
+		t.Errorf("got sessions %+v after loading, want %+v", got, sessions)
+	}
+}
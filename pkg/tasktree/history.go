@@ -0,0 +1,320 @@
+package tasktree
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+)
+
+// DefaultHistoryDepth is the default number of operations that can be undone.
+const DefaultHistoryDepth = 100
+
+// ErrNothingToUndo is returned by Undo when there is no operation to undo.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrNothingToRedo is returned by Redo when there is no operation to redo.
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// A change is a single reversible modification of the state of a TaskTree.
+type change struct {
+	apply  func()
+	revert func()
+}
+
+// An operation groups the changes made by a single call to a mutating method,
+// so that they are undone and redone together.
+type operation struct {
+	name    string
+	changes []change
+}
+
+func (op *operation) apply() {
+	for _, c := range op.changes {
+		c.apply()
+	}
+}
+
+func (op *operation) revert() {
+	for i := len(op.changes) - 1; i >= 0; i-- {
+		op.changes[i].revert()
+	}
+}
+
+// Undo reverts the most recent operation and returns its name (e.g. "delete task").
+func (tree *TaskTree) Undo() (string, error) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if len(tree.undoStack) == 0 {
+		return "", ErrNothingToUndo
+	}
+
+	op := tree.undoStack[len(tree.undoStack)-1]
+	tree.undoStack = tree.undoStack[:len(tree.undoStack)-1]
+	op.revert()
+	tree.redoStack = append(tree.redoStack, op)
+	tree.revision++
+	return op.name, nil
+}
+
+// Redo reapplies the most recently undone operation and returns its name.
+func (tree *TaskTree) Redo() (string, error) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if len(tree.redoStack) == 0 {
+		return "", ErrNothingToRedo
+	}
+
+	op := tree.redoStack[len(tree.redoStack)-1]
+	tree.redoStack = tree.redoStack[:len(tree.redoStack)-1]
+	op.apply()
+	tree.pushUndo(op)
+	tree.revision++
+	return op.name, nil
+}
+
+// SetHistoryDepth sets the maximum number of operations that can be undone.
+// Older operations are forgotten.
+func (tree *TaskTree) SetHistoryDepth(depth int) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	tree.historyDepth = max(depth, 0)
+	if len(tree.undoStack) > tree.historyDepth {
+		tree.undoStack = tree.undoStack[len(tree.undoStack)-tree.historyDepth:]
+	}
+	if len(tree.redoStack) > tree.historyDepth {
+		tree.redoStack = tree.redoStack[len(tree.redoStack)-tree.historyDepth:]
+	}
+}
+
+// clearHistory forgets every operation. The caller must hold the write lock.
+func (tree *TaskTree) clearHistory() {
+	tree.undoStack = nil
+	tree.redoStack = nil
+}
+
+func (tree *TaskTree) pushUndo(op *operation) {
+	tree.undoStack = append(tree.undoStack, op)
+	if len(tree.undoStack) > tree.historyDepth {
+		tree.undoStack = tree.undoStack[len(tree.undoStack)-tree.historyDepth:]
+	}
+}
+
+// mutate runs f as a single undoable operation. If f returns an error, every
+// change it made is reverted, so operations are atomic. Nested calls join the
+// outermost operation. The caller must hold the write lock.
+func (tree *TaskTree) mutate(name string, f func() error) error {
+	if tree.pending != nil {
+		return f()
+	}
+
+	tree.pending = &operation{name: name}
+	err := f()
+	op := tree.pending
+	tree.pending = nil
+
+	if err != nil {
+		op.revert()
+		return err
+	}
+	if len(op.changes) == 0 {
+		return nil
+	}
+
+	tree.pushUndo(op)
+	tree.redoStack = nil
+	tree.revision++
+	return nil
+}
+
+// record applies a change as part of the pending operation.
+func (tree *TaskTree) record(c change) {
+	c.apply()
+	if tree.pending != nil {
+		tree.pending.changes = append(tree.pending.changes, c)
+	}
+}
+
+// The following primitives are the only way the structure of a TaskTree is
+// modified, so that every modification can be undone. The caller must hold
+// the write lock and be inside of mutate.
+
+// setTask adds a task or replaces an existing one.
+func (tree *TaskTree) setTask(t task.Task) {
+	old, existed := tree.tasks[t.Id]
+	tree.record(change{
+		apply: func() { tree.tasks[t.Id] = t },
+		revert: func() {
+			if existed {
+				tree.tasks[t.Id] = old
+			} else {
+				delete(tree.tasks, t.Id)
+			}
+		},
+	})
+}
+
+// removeTask removes a task. It must already be detached from its parent and
+// have no subtasks or blockers left. The task's (empty) entries in the subtask
+// and blocker maps are removed along with it.
+func (tree *TaskTree) removeTask(id task.Id) {
+	old := tree.tasks[id]
+	subtasks, hadSubtasks := tree.subtasks[id]
+	blocks, hadBlocks := tree.blocks[id]
+	blockedBy, hadBlockedBy := tree.blockedBy[id]
+	tree.record(change{
+		apply: func() {
+			delete(tree.tasks, id)
+			delete(tree.subtasks, id)
+			delete(tree.blocks, id)
+			delete(tree.blockedBy, id)
+		},
+		revert: func() {
+			tree.tasks[id] = old
+			if hadSubtasks {
+				tree.subtasks[id] = subtasks
+			}
+			if hadBlocks {
+				tree.blocks[id] = blocks
+			}
+			if hadBlockedBy {
+				tree.blockedBy[id] = blockedBy
+			}
+		},
+	})
+}
+
+// siblings returns the subtasks of a parent task, or the root tasks if parentId is empty.
+func (tree *TaskTree) siblings(parentId task.Id) []task.Id {
+	if parentId == "" {
+		return tree.roots
+	}
+	return tree.subtasks[parentId]
+}
+
+func (tree *TaskTree) setSiblings(parentId task.Id, ids []task.Id) {
+	if parentId == "" {
+		tree.roots = ids
+	} else {
+		tree.subtasks[parentId] = ids
+	}
+}
+
+// attach inserts a detached task at a given index among the subtasks of a
+// parent task, or among the root tasks if parentId is empty. A negative or
+// out of range index appends the task.
+func (tree *TaskTree) attach(id task.Id, parentId task.Id, index int) {
+	if index < 0 || index > len(tree.siblings(parentId)) {
+		index = len(tree.siblings(parentId))
+	}
+	_, hadSubtasks := tree.subtasks[parentId]
+	tree.record(change{
+		apply: func() {
+			tree.setSiblings(parentId, slices.Insert(slices.Clone(tree.siblings(parentId)), index, id))
+			if parentId != "" {
+				tree.subtaskOf[id] = parentId
+			}
+		},
+		revert: func() {
+			tree.setSiblings(parentId, slices.Delete(slices.Clone(tree.siblings(parentId)), index, index+1))
+			if parentId != "" && !hadSubtasks {
+				delete(tree.subtasks, parentId)
+			}
+			delete(tree.subtaskOf, id)
+		},
+	})
+}
+
+// reorder replaces the order of the subtasks of a parent task, or of the root
+// tasks if parentId is empty. ids must be a permutation of the current order.
+func (tree *TaskTree) reorder(parentId task.Id, ids []task.Id) {
+	old := tree.siblings(parentId)
+	tree.record(change{
+		apply:  func() { tree.setSiblings(parentId, ids) },
+		revert: func() { tree.setSiblings(parentId, old) },
+	})
+}
+
+// detach removes a task from its parent task (or the root tasks), without
+// removing the task itself. Returns the parent (empty for root tasks) and the
+// index the task had among its siblings.
+func (tree *TaskTree) detach(id task.Id) (task.Id, int) {
+	parentId := tree.subtaskOf[id]
+	index := slices.Index(tree.siblings(parentId), id)
+	if index < 0 {
+		return parentId, index
+	}
+	tree.record(change{
+		apply: func() {
+			tree.setSiblings(parentId, slices.Delete(slices.Clone(tree.siblings(parentId)), index, index+1))
+			delete(tree.subtaskOf, id)
+		},
+		revert: func() {
+			tree.setSiblings(parentId, slices.Insert(slices.Clone(tree.siblings(parentId)), index, id))
+			if parentId != "" {
+				tree.subtaskOf[id] = parentId
+			}
+		},
+	})
+	return parentId, index
+}
+
+// setCompletionPolicy sets the policy applied by CompleteTask and ReopenTask.
+func (tree *TaskTree) setCompletionPolicy(policy CompletionPolicy) {
+	old := tree.completionPolicy
+	tree.record(change{
+		apply:  func() { tree.completionPolicy = policy },
+		revert: func() { tree.completionPolicy = old },
+	})
+}
+
+// setSessions replaces the time log.
+func (tree *TaskTree) setSessions(sessions []Session) {
+	old := tree.sessions
+	tree.record(change{
+		apply:  func() { tree.sessions = sessions },
+		revert: func() { tree.sessions = old },
+	})
+}
+
+// addBlock marks one task as blocking another.
+func (tree *TaskTree) addBlock(blockerId task.Id, blockedId task.Id) {
+	blocks, hadBlocks := tree.blocks[blockerId]
+	blockedBy, hadBlockedBy := tree.blockedBy[blockedId]
+	tree.record(change{
+		apply: func() {
+			tree.blocks[blockerId] = append(slices.Clone(tree.blocks[blockerId]), blockedId)
+			tree.blockedBy[blockedId] = append(slices.Clone(tree.blockedBy[blockedId]), blockerId)
+		},
+		revert: func() {
+			if hadBlocks {
+				tree.blocks[blockerId] = blocks
+			} else {
+				delete(tree.blocks, blockerId)
+			}
+			if hadBlockedBy {
+				tree.blockedBy[blockedId] = blockedBy
+			} else {
+				delete(tree.blockedBy, blockedId)
+			}
+		},
+	})
+}
+
+// removeBlock marks one task as no longer blocking another.
+func (tree *TaskTree) removeBlock(blockerId task.Id, blockedId task.Id) {
+	blocksIndex := slices.Index(tree.blocks[blockerId], blockedId)
+	blockedByIndex := slices.Index(tree.blockedBy[blockedId], blockerId)
+	tree.record(change{
+		apply: func() {
+			tree.blocks[blockerId] = slices.Delete(slices.Clone(tree.blocks[blockerId]), blocksIndex, blocksIndex+1)
+			tree.blockedBy[blockedId] = slices.Delete(slices.Clone(tree.blockedBy[blockedId]), blockedByIndex, blockedByIndex+1)
+		},
+		revert: func() {
+			tree.blocks[blockerId] = slices.Insert(slices.Clone(tree.blocks[blockerId]), blocksIndex, blockedId)
+			tree.blockedBy[blockedId] = slices.Insert(slices.Clone(tree.blockedBy[blockedId]), blockedByIndex, blockerId)
+		},
+	})
+}
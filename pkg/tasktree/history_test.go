@@ -0,0 +1,421 @@
+package tasktree
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// treeState is a deep copy of the state of a TaskTree that operations change.
+type treeState struct {
+	tasks            map[task.Id]task.Task
+	roots            []task.Id
+	subtasks         map[task.Id][]task.Id
+	subtaskOf        map[task.Id]task.Id
+	blocks           map[task.Id][]task.Id
+	blockedBy        map[task.Id][]task.Id
+	completionPolicy CompletionPolicy
+	sessions         []Session
+}
+
+func cloneIdLists(m map[task.Id][]task.Id) map[task.Id][]task.Id {
+	clone := make(map[task.Id][]task.Id, len(m))
+	for id, ids := range m {
+		clone[id] = slices.Clone(ids)
+	}
+	return clone
+}
+
+func snapshot(tree *TaskTree) treeState {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	return treeState{
+		tasks:            maps.Clone(tree.tasks),
+		roots:            slices.Clone(tree.roots),
+		subtasks:         cloneIdLists(tree.subtasks),
+		subtaskOf:        maps.Clone(tree.subtaskOf),
+		blocks:           cloneIdLists(tree.blocks),
+		blockedBy:        cloneIdLists(tree.blockedBy),
+		completionPolicy: tree.completionPolicy,
+		sessions:         slices.Clone(tree.sessions),
+	}
+}
+
+// fields returns the parts of a treeState by name.
+func (s treeState) fields() map[string]any {
+	return map[string]any{
+		"tasks":            s.tasks,
+		"roots":            s.roots,
+		"subtasks":         s.subtasks,
+		"subtaskOf":        s.subtaskOf,
+		"blocks":           s.blocks,
+		"blockedBy":        s.blockedBy,
+		"completionPolicy": s.completionPolicy,
+		"sessions":         s.sessions,
+	}
+}
+
+// assertState fails if the state of a tree differs from an earlier snapshot.
+func assertState(t *testing.T, tree *TaskTree, want treeState, when string) {
+	t.Helper()
+	got := snapshot(tree).fields()
+	for name, wantField := range want.fields() {
+		if !reflect.DeepEqual(got[name], wantField) {
+			t.Errorf("%v, got %v:\n%+v\nwant:\n%+v", when, name, got[name], wantField)
+		}
+	}
+}
+
+// newHistoryTestTree creates a tree with a history of its own, returning the
+// ids of its tasks by name:
+//
+//	a
+//	  a1 (blocks b)
+//	  a2 !urgent, with logged time
+//	  a3
+//	b
+//	  b1
+//	c, recurring daily
+func newHistoryTestTree(t *testing.T) (*TaskTree, map[string]task.Id) {
+	t.Helper()
+	tree := NewTaskTree()
+	ids := make(map[string]task.Id)
+	add := func(name string, parent string, edit func(*task.Task)) {
+		tsk := newTestTask(name)
+		if edit != nil {
+			edit(&tsk)
+		}
+		ids[name] = mustAdd(t, tree, ids[parent], tsk).Id
+	}
+
+	add("a", "", nil)
+	add("a1", "a", nil)
+	add("a2", "a", func(t *task.Task) { t.Priority = task.Urgent })
+	add("a3", "a", nil)
+	add("b", "", nil)
+	add("b1", "b", nil)
+	add("c", "", func(tsk *task.Task) {
+		recurrence, err := task.ParseRecurrence("daily")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tsk.Recurrence = recurrence
+		tsk.Due = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.Local)
+	})
+
+	now := time.Now()
+	for _, err := range []error{
+		tree.MarkBlocker(ids["a1"], ids["b"]),
+		tree.LogTime(ids["a2"], now.Add(-time.Hour), now),
+	} {
+		if err != nil {
+			t.Fatalf("failed to build test tree: %v", err)
+		}
+	}
+	return tree, ids
+}
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(tree *TaskTree, ids map[string]task.Id) error
+	}{
+		{"add task", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.AddTask(newTestTask("d"))
+		}},
+		{"add subtask", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.AddSubtask(ids["b1"], newTestTask("b1a"))
+		}},
+		{"add task with invested time", func(tree *TaskTree, ids map[string]task.Id) error {
+			tsk := newTestTask("d")
+			tsk.TimeInvested = time.Hour
+			return tree.AddTask(tsk)
+		}},
+		{"update task", func(tree *TaskTree, ids map[string]task.Id) error {
+			tsk, _ := tree.GetTask(ids["a1"])
+			tsk.Name = "renamed"
+			tsk.Tags = []task.Tag{"new"}
+			return tree.UpdateTask(tsk)
+		}},
+		{"delete and reparent", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.DeleteTaskWithPolicy(ids["a"], ReparentToGrandparent)
+		}},
+		{"delete and promote", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.DeleteTaskWithPolicy(ids["a"], PromoteChildren)
+		}},
+		{"delete blocker", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.DeleteTaskWithPolicy(ids["a1"], ReparentToGrandparent)
+		}},
+		{"delete subtree", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.DeleteSubtree(ids["a"])
+		}},
+		{"move task", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MoveTask(ids["a2"], ids["b"], 0)
+		}},
+		{"move task to the roots", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MoveTask(ids["b1"], "", 1)
+		}},
+		{"indent", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.IndentTask(ids["a3"])
+		}},
+		{"outdent", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.OutdentTask(ids["a2"])
+		}},
+		{"move up", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MoveUp(ids["a3"])
+		}},
+		{"move down", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MoveDown(ids["a"])
+		}},
+		{"move to top", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MoveToTop(ids["c"])
+		}},
+		{"move to bottom", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MoveToBottom(ids["a1"])
+		}},
+		{"sort subtasks", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.SortSubtasks(ids["a"], SortByPriority)
+		}},
+		{"mark blocker", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MarkBlocker(ids["c"], ids["a3"])
+		}},
+		{"unmark blocker", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.UnmarkBlocker(ids["a1"], ids["b"])
+		}},
+		{"mark subtask", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.MarkSubtask(ids["b1"], ids["c"])
+		}},
+		{"unmark subtask", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.UnmarkSubtask(ids["a2"])
+		}},
+		{"complete task", func(tree *TaskTree, ids map[string]task.Id) error {
+			_, _, err := tree.CompleteTask(ids["a1"])
+			return err
+		}},
+		{"complete recurring task", func(tree *TaskTree, ids map[string]task.Id) error {
+			_, _, err := tree.CompleteTask(ids["c"])
+			return err
+		}},
+		{"complete with cascade", func(tree *TaskTree, ids map[string]task.Id) error {
+			if err := tree.SetCompletionPolicy(CascadeDown); err != nil {
+				return err
+			}
+			_, _, err := tree.CompleteTask(ids["a"])
+			return err
+		}},
+		{"set completion policy", func(tree *TaskTree, ids map[string]task.Id) error {
+			return tree.SetCompletionPolicy(AutoCompleteParent)
+		}},
+		{"start timer", func(tree *TaskTree, ids map[string]task.Id) error {
+			_, _, err := tree.StartTimer(ids["b1"])
+			return err
+		}},
+		{"log time", func(tree *TaskTree, ids map[string]task.Id) error {
+			now := time.Now()
+			return tree.LogTime(ids["a3"], now.Add(-time.Minute), now)
+		}},
+		{"log pomodoro", func(tree *TaskTree, ids map[string]task.Id) error {
+			now := time.Now()
+			return tree.LogPomodoro(ids["a2"], now.Add(-25*time.Minute), now)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, ids := newHistoryTestTree(t)
+			before := snapshot(tree)
+			depth := len(tree.undoStack)
+
+			if err := tt.mutate(tree, ids); err != nil {
+				t.Fatalf("mutation failed: %v", err)
+			}
+			after := snapshot(tree)
+			if reflect.DeepEqual(before, after) {
+				t.Fatalf("mutation changed nothing")
+			}
+
+			// Some mutations are made of several operations.
+			undone := len(tree.undoStack) - depth
+			for i := 0; i < undone; i++ {
+				if _, err := tree.Undo(); err != nil {
+					t.Fatalf("undo failed: %v", err)
+				}
+			}
+			assertState(t, tree, before, "after undo")
+
+			for i := 0; i < undone; i++ {
+				if _, err := tree.Redo(); err != nil {
+					t.Fatalf("redo failed: %v", err)
+				}
+			}
+			assertState(t, tree, after, "after redo")
+
+			for i := 0; i < undone; i++ {
+				if _, err := tree.Undo(); err != nil {
+					t.Fatalf("second undo failed: %v", err)
+				}
+			}
+			assertState(t, tree, before, "after undoing the redo")
+		})
+	}
+}
+
+func TestFailedMutationChangesNothing(t *testing.T) {
+	tree, ids := newHistoryTestTree(t)
+	before := snapshot(tree)
+	revision := tree.Revision()
+
+	// The blocker of b is inherited by b1, so this would be a cycle.
+	if err := tree.MarkBlocker(ids["b1"], ids["a1"]); err == nil {
+		t.Fatal("marking a cyclic blocker succeeded")
+	}
+	if err := tree.MarkSubtask(ids["a1"], ids["a"]); err == nil {
+		t.Fatal("making a task a subtask of its subtask succeeded")
+	}
+	if err := tree.MoveTask(ids["a"], ids["a1"], 0); err == nil {
+		t.Fatal("moving a task under its subtask succeeded")
+	}
+	assertState(t, tree, before, "after failed mutations")
+	if tree.Revision() != revision {
+		t.Errorf("failed mutations changed the revision from %d to %d", revision, tree.Revision())
+	}
+}
+
+func TestHistoryStacks(t *testing.T) {
+	tree := NewTaskTree()
+	if _, err := tree.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("undo on a new tree returned %v, want %v", err, ErrNothingToUndo)
+	}
+	if _, err := tree.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("redo on a new tree returned %v, want %v", err, ErrNothingToRedo)
+	}
+
+	a := mustAdd(t, tree, "", newTestTask("a"))
+	mustAdd(t, tree, "", newTestTask("b"))
+	if name, err := tree.Undo(); err != nil || name != "add task" {
+		t.Errorf("undo returned %q, %v, want \"add task\"", name, err)
+	}
+
+	// A new operation forgets the undone one.
+	if err := tree.UpdateTask(task.Task{Id: a.Id, Name: "renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("redo after a new operation returned %v, want %v", err, ErrNothingToRedo)
+	}
+
+	// Only the most recent operations are kept.
+	tree.SetHistoryDepth(1)
+	if _, err := tree.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("undo past the history depth returned %v, want %v", err, ErrNothingToUndo)
+	}
+}
+
+func TestPrimitives(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(tree *TaskTree, ids map[string]task.Id)
+	}{
+		{"set new task", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.setTask(newTestTask("d"))
+		}},
+		{"set existing task", func(tree *TaskTree, ids map[string]task.Id) {
+			tsk := tree.tasks[ids["a1"]]
+			tsk.Description = "changed"
+			tree.setTask(tsk)
+		}},
+		{"detach and remove task", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.detach(ids["a3"])
+			tree.removeTask(ids["a3"])
+		}},
+		{"detach root", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.detach(ids["b"])
+		}},
+		{"detach and attach", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.detach(ids["a2"])
+			tree.attach(ids["a2"], ids["b1"], 0)
+		}},
+		{"detach and attach to the roots", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.detach(ids["a1"])
+			tree.attach(ids["a1"], "", 1)
+		}},
+		{"reorder", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.reorder(ids["a"], []task.Id{ids["a3"], ids["a1"], ids["a2"]})
+		}},
+		{"reorder roots", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.reorder("", []task.Id{ids["c"], ids["a"], ids["b"]})
+		}},
+		{"add block", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.addBlock(ids["a1"], ids["c"])
+		}},
+		{"add first block", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.addBlock(ids["a3"], ids["a2"])
+		}},
+		{"remove block", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.removeBlock(ids["a1"], ids["b"])
+		}},
+		{"add and remove blocks", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.addBlock(ids["a1"], ids["c"])
+			tree.addBlock(ids["a2"], ids["c"])
+			tree.removeBlock(ids["a1"], ids["c"])
+		}},
+		{"set sessions", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.setSessions(append(slices.Clone(tree.sessions), Session{TaskId: ids["c"], Start: time.Now()}))
+		}},
+		{"clear sessions", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.setSessions(nil)
+		}},
+		{"set completion policy", func(tree *TaskTree, ids map[string]task.Id) {
+			tree.setCompletionPolicy(RequireSubtasksComplete)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, ids := newHistoryTestTree(t)
+			before := snapshot(tree)
+
+			tree.rwMu.Lock()
+			err := tree.mutate(tt.name, func() error {
+				tt.change(tree, ids)
+				return nil
+			})
+			tree.rwMu.Unlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := snapshot(tree)
+
+			if _, err := tree.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			assertState(t, tree, before, "after undo")
+			if _, err := tree.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			assertState(t, tree, after, "after redo")
+
+			// Reverting a failed operation undoes its changes too.
+			tree, ids = newHistoryTestTree(t)
+			before = snapshot(tree)
+			tree.rwMu.Lock()
+			err = tree.mutate(tt.name, func() error {
+				tt.change(tree, ids)
+				return errors.New("failed")
+			})
+			tree.rwMu.Unlock()
+			if err == nil {
+				t.Fatal("failed operation succeeded")
+			}
+			assertState(t, tree, before, "after a failed operation")
+		})
+	}
+}
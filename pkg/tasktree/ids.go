@@ -0,0 +1,133 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"strconv"
+	"strings"
+)
+
+// AliasPrefix is the prefix of the aliases assigned to tasks, which are
+// followed by a sequence number (e.g. "t42").
+const AliasPrefix = "t"
+
+// MinPrefixLength is the minimum length of a task id prefix accepted by Resolve,
+// so that short names aren't mistaken for id prefixes.
+const MinPrefixLength = 4
+
+// An AmbiguousRefError is returned by Resolve when a reference matches several tasks.
+type AmbiguousRefError struct {
+	Ref     string
+	Matches []task.Task
+}
+
+func (e *AmbiguousRefError) Error() string {
+	matches := make([]string, len(e.Matches))
+	for i, t := range e.Matches {
+		matches[i] = fmt.Sprintf("%v (%v)", t.Alias, t.Name)
+	}
+	return fmt.Sprintf("%q is ambiguous, it matches %v", e.Ref, strings.Join(matches, ", "))
+}
+
+// Resolve finds the task a reference typed by a user refers to. A reference
+// is, in order of precedence, the exact id of a task, its alias, its exact
+// name, or a prefix of its id at least MinPrefixLength characters long.
+// Returns an *AmbiguousRefError if the first of these that matches anything
+// matches several tasks.
+func (tree *TaskTree) Resolve(ref string) (task.Id, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if _, exists := tree.tasks[task.Id(ref)]; exists {
+		return task.Id(ref), nil
+	}
+
+	matchers := []func(t task.Task) bool{
+		func(t task.Task) bool { return strings.EqualFold(t.Alias, ref) },
+		func(t task.Task) bool { return t.Name == ref },
+		func(t task.Task) bool {
+			return len(ref) >= MinPrefixLength && strings.HasPrefix(string(t.Id), strings.ToLower(ref))
+		},
+	}
+	for _, matches := range matchers {
+		res := make([]task.Task, 0)
+		// Tasks are checked in depth-first order so that ambiguity errors are listed in tree order.
+		for _, rootId := range tree.roots {
+			for _, id := range tree.subtreeIds(rootId) {
+				if matches(tree.tasks[id]) {
+					res = append(res, tree.tasks[id])
+				}
+			}
+		}
+
+		switch len(res) {
+		case 0:
+			continue
+		case 1:
+			return res[0].Id, nil
+		default:
+			return "", &AmbiguousRefError{Ref: ref, Matches: res}
+		}
+	}
+
+	return "", fmt.Errorf("no task matches %q", ref)
+}
+
+// label returns the alias of a task for use in error messages, or its id if
+// it doesn't have one. The caller must hold the lock.
+func (tree *TaskTree) label(id task.Id) string {
+	if t, exists := tree.tasks[id]; exists && t.Alias != "" {
+		return t.Alias
+	}
+	return string(id)
+}
+
+// aliasNumber returns the sequence number of an alias assigned by the TaskTree.
+func aliasNumber(alias string) (int, bool) {
+	if !strings.HasPrefix(alias, AliasPrefix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(alias, AliasPrefix))
+	return n, err == nil && n > 0
+}
+
+// highestAlias returns the highest sequence number of the aliases in use.
+func (tree *TaskTree) highestAlias() int {
+	highest := 0
+	for _, t := range tree.tasks {
+		if n, ok := aliasNumber(t.Alias); ok {
+			highest = max(highest, n)
+		}
+	}
+	return highest
+}
+
+// nextAlias returns an alias that isn't used by any task.
+func (tree *TaskTree) nextAlias() string {
+	return AliasPrefix + strconv.Itoa(tree.highestAlias()+1)
+}
+
+// checkAlias checks that no other task has the same alias as a task.
+func (tree *TaskTree) checkAlias(t task.Task) error {
+	for _, other := range tree.tasks {
+		if other.Id != t.Id && strings.EqualFold(other.Alias, t.Alias) {
+			return fmt.Errorf("alias %v is already used by task %v", t.Alias, other.Name)
+		}
+	}
+	return nil
+}
+
+// assignAliases assigns an alias to every task that doesn't have one, in
+// depth-first order. Used when loading trees saved before tasks had aliases.
+func (tree *TaskTree) assignAliases() {
+	next := tree.highestAlias() + 1
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			if t := tree.tasks[id]; t.Alias == "" {
+				t.Alias = AliasPrefix + strconv.Itoa(next)
+				tree.tasks[id] = t
+				next++
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tasktree
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tree := NewTaskTree()
+	// Tasks are assigned the aliases t1 to t6 in order.
+	for _, tsk := range []task.Task{
+		{Id: "abcd1111", Name: "write"},
+		{Id: "abcd2222", Name: "t1"},
+		{Id: "ef001111", Name: "review"},
+		{Id: "ef002222", Name: "review"},
+		{Id: "12345678", Name: "ef001111"},
+		{Id: "77777777", Name: "ef00"},
+	} {
+		mustAdd(t, tree, "", tsk)
+	}
+
+	tests := []struct {
+		name string
+		ref  string
+		want task.Id
+		// wantAmbiguous are the aliases of the tasks an ambiguous reference
+		// matches, and wantErr whether it matches nothing.
+		wantAmbiguous []string
+		wantErr       bool
+	}{
+		{name: "id", ref: "abcd2222", want: "abcd2222"},
+		{name: "id before name", ref: "ef001111", want: "ef001111"},
+		{name: "alias", ref: "t3", want: "ef001111"},
+		{name: "alias in another case", ref: "T3", want: "ef001111"},
+		{name: "alias before name", ref: "t1", want: "abcd1111"},
+		{name: "name", ref: "write", want: "abcd1111"},
+		{name: "ambiguous name", ref: "review", wantAmbiguous: []string{"t3", "t4"}},
+		{name: "name before id prefix", ref: "ef00", want: "77777777"},
+		{name: "id prefix", ref: "abcd1", want: "abcd1111"},
+		{name: "id prefix in another case", ref: "ABCD2", want: "abcd2222"},
+		{name: "minimum id prefix", ref: "1234", want: "12345678"},
+		{name: "ambiguous id prefix", ref: "abcd", wantAmbiguous: []string{"t1", "t2"}},
+		{name: "short id prefix", ref: "123", wantErr: true},
+		{name: "no match", ref: "nothing", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := tree.Resolve(test.ref)
+			var ambiguous *AmbiguousRefError
+			switch {
+			case test.wantAmbiguous != nil:
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("got %v, %v, want an AmbiguousRefError", got, err)
+				}
+				aliases := make([]string, len(ambiguous.Matches))
+				for i, match := range ambiguous.Matches {
+					aliases[i] = match.Alias
+				}
+				if !slices.Equal(aliases, test.wantAmbiguous) {
+					t.Errorf("got matches %v, want %v", aliases, test.wantAmbiguous)
+				}
+			case test.wantErr:
+				if err == nil || errors.As(err, &ambiguous) {
+					t.Errorf("got %v, %v, want no match", got, err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case got != test.want:
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
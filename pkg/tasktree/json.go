@@ -0,0 +1,377 @@
+package tasktree
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the JSON representation of a TaskTree
+// written by MarshalJSON. UnmarshalJSON accepts this version and every older one.
+//
+// Version 2 added the dates and recurrence of tasks and the time log. Version 1
+// files are migrated by logging the time invested in each task as a legacy
+// session, see Session.Legacy.
+const JSONSchemaVersion = 2
+
+// jsonTaskTree is the JSON representation of a TaskTree. Like the gob
+// encoding, it only contains the tasks, roots, subtasks, blocks, completion
+// policy and time log, from which everything else is reconstructed.
+type jsonTaskTree struct {
+	Version  int                   `json:"version"`
+	Tasks    []jsonTask            `json:"tasks"`
+	Roots    []task.Id             `json:"roots"`
+	Subtasks map[task.Id][]task.Id `json:"subtasks"`
+	Blocks   map[task.Id][]task.Id `json:"blocks"`
+
+	CompletionPolicy string        `json:"completion_policy,omitempty"`
+	Sessions         []jsonSession `json:"sessions,omitempty"`
+}
+
+// jsonSession is the JSON representation of a Session. The end is omitted
+// while the session is the active timer.
+type jsonSession struct {
+	Task  task.Id `json:"task"`
+	Start string  `json:"start"`
+	End   string  `json:"end,omitempty"`
+
+	Pomodoro bool `json:"pomodoro,omitempty"`
+	Legacy   bool `json:"legacy,omitempty"`
+}
+
+// jsonTask is the JSON representation of a task.Task. Durations are written
+// as strings such as "1h30m0s", times in RFC 3339 format and priorities by
+// name to keep files readable.
+type jsonTask struct {
+	Id            task.Id    `json:"id"`
+	Alias         string     `json:"alias,omitempty"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description,omitempty"`
+	EstimatedTime string     `json:"estimated_time,omitempty"`
+	TimeInvested  string     `json:"time_invested,omitempty"`
+	Completed     bool       `json:"completed"`
+	Tags          []task.Tag `json:"tags,omitempty"`
+	Priority      string     `json:"priority,omitempty"`
+	Due           string     `json:"due,omitempty"`
+	Start         string     `json:"start,omitempty"`
+	CompletedAt   string     `json:"completed_at,omitempty"`
+	Recurrence    string     `json:"recurrence,omitempty"`
+}
+
+func newJSONTask(t task.Task) jsonTask {
+	res := jsonTask{
+		Id:          t.Id,
+		Alias:       t.Alias,
+		Name:        t.Name,
+		Description: t.Description,
+		Completed:   t.Completed,
+		Tags:        t.Tags,
+	}
+	if t.EstimatedTime != 0 {
+		res.EstimatedTime = t.EstimatedTime.String()
+	}
+	if t.TimeInvested != 0 {
+		res.TimeInvested = t.TimeInvested.String()
+	}
+	if t.Priority != task.Default {
+		res.Priority = t.Priority.String()
+	}
+	res.Due = formatJSONTime(t.Due)
+	res.Start = formatJSONTime(t.Start)
+	res.CompletedAt = formatJSONTime(t.CompletedAt)
+	if !t.Recurrence.IsZero() {
+		res.Recurrence = t.Recurrence.String()
+	}
+	return res
+}
+
+func (t jsonTask) toTask() (task.Task, error) {
+	res := task.Task{
+		Id:          t.Id,
+		Alias:       t.Alias,
+		Name:        t.Name,
+		Description: t.Description,
+		Completed:   t.Completed,
+		Tags:        t.Tags,
+	}
+
+	var err error
+	if t.EstimatedTime != "" {
+		if res.EstimatedTime, err = time.ParseDuration(t.EstimatedTime); err != nil {
+			return task.Task{}, fmt.Errorf("task %v: invalid estimated_time: %w", t.Id, err)
+		}
+	}
+	if t.TimeInvested != "" {
+		if res.TimeInvested, err = time.ParseDuration(t.TimeInvested); err != nil {
+			return task.Task{}, fmt.Errorf("task %v: invalid time_invested: %w", t.Id, err)
+		}
+	}
+	if t.Priority != "" {
+		if res.Priority, err = task.ParsePriority(t.Priority); err != nil {
+			return task.Task{}, fmt.Errorf("task %v: %w", t.Id, err)
+		}
+	}
+	if res.Due, err = parseJSONTime(t.Due); err != nil {
+		return task.Task{}, fmt.Errorf("task %v: invalid due: %w", t.Id, err)
+	}
+	if res.Start, err = parseJSONTime(t.Start); err != nil {
+		return task.Task{}, fmt.Errorf("task %v: invalid start: %w", t.Id, err)
+	}
+	if res.CompletedAt, err = parseJSONTime(t.CompletedAt); err != nil {
+		return task.Task{}, fmt.Errorf("task %v: invalid completed_at: %w", t.Id, err)
+	}
+	if t.Recurrence != "" {
+		if res.Recurrence, err = task.ParseRecurrence(t.Recurrence); err != nil {
+			return task.Task{}, fmt.Errorf("task %v: %w", t.Id, err)
+		}
+	}
+
+	return res, nil
+}
+
+// formatJSONTime formats a time in RFC 3339 format, or as an empty string if it is zero.
+func formatJSONTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseJSONTime parses a time in RFC 3339 format or a date such as
+// "2026-11-03", which is taken as midnight in the local time zone. An empty
+// string is the zero time.
+func parseJSONTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.ParseInLocation(task.DateLayout, s, time.Local); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// MarshalJSON encodes a TaskTree as JSON. Tasks are written in depth-first order.
+func (tree *TaskTree) MarshalJSON() ([]byte, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	res := jsonTaskTree{
+		Version:  JSONSchemaVersion,
+		Tasks:    make([]jsonTask, 0, len(tree.tasks)),
+		Roots:    tree.roots,
+		Subtasks: make(map[task.Id][]task.Id),
+		Blocks:   make(map[task.Id][]task.Id),
+	}
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			res.Tasks = append(res.Tasks, newJSONTask(tree.tasks[id]))
+		}
+	}
+	for parentId, subtaskIds := range tree.subtasks {
+		if len(subtaskIds) > 0 {
+			res.Subtasks[parentId] = subtaskIds
+		}
+	}
+	for blockerId, blockedIds := range tree.blocks {
+		if len(blockedIds) > 0 {
+			res.Blocks[blockerId] = blockedIds
+		}
+	}
+	if tree.completionPolicy != Independent {
+		res.CompletionPolicy = tree.completionPolicy.String()
+	}
+	for _, s := range tree.sessions {
+		res.Sessions = append(res.Sessions, jsonSession{Task: s.TaskId, Start: formatJSONTime(s.Start), End: formatJSONTime(s.End), Pomodoro: s.Pomodoro, Legacy: s.Legacy})
+	}
+
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON decodes a TaskTree from JSON, replacing the contents of the
+// TaskTree. The data is validated for referential integrity: every referenced
+// task must exist (except in the sessions of deleted tasks), each task may
+// have at most one parent, and neither the subtask hierarchy nor the blocker
+// graph may contain cycles. Root tasks missing from the "roots" list are
+// appended in the order they appear in "tasks", and tasks without an alias
+// are assigned one. Time invested in a task beyond what its sessions add up
+// to is logged as a legacy session ending now.
+func (tree *TaskTree) UnmarshalJSON(data []byte) error {
+	var in jsonTaskTree
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	if in.Version == 0 {
+		return fmt.Errorf("missing schema version")
+	} else if in.Version > JSONSchemaVersion {
+		return fmt.Errorf("unsupported schema version %d, expected at most %d", in.Version, JSONSchemaVersion)
+	}
+
+	decoded := NewTaskTree()
+	if in.CompletionPolicy != "" {
+		policy, err := ParseCompletionPolicy(in.CompletionPolicy)
+		if err != nil {
+			return err
+		}
+		decoded.completionPolicy = policy
+	}
+	order := make([]task.Id, 0, len(in.Tasks))
+	for _, jt := range in.Tasks {
+		t, err := jt.toTask()
+		if err != nil {
+			return err
+		}
+		if t.Id == "" {
+			return fmt.Errorf("task %q has an empty id", t.Name)
+		}
+		if _, exists := decoded.tasks[t.Id]; exists {
+			return fmt.Errorf("duplicate task id %v", t.Id)
+		}
+		if t.Alias != "" {
+			if err := decoded.checkAlias(t); err != nil {
+				return err
+			}
+		}
+		decoded.tasks[t.Id] = t
+		order = append(order, t.Id)
+	}
+
+	for parentId, subtaskIds := range in.Subtasks {
+		if err := decoded.assertTaskExists(parentId); err != nil {
+			return fmt.Errorf("subtasks: %w", err)
+		}
+		for _, subtaskId := range subtaskIds {
+			if err := decoded.assertTaskExists(subtaskId); err != nil {
+				return fmt.Errorf("subtasks of %v: %w", parentId, err)
+			}
+			if existingParentId, exists := decoded.subtaskOf[subtaskId]; exists {
+				return fmt.Errorf("task %v is a subtask of both %v and %v", subtaskId, existingParentId, parentId)
+			}
+			decoded.subtaskOf[subtaskId] = parentId
+		}
+		decoded.subtasks[parentId] = subtaskIds
+	}
+	for _, id := range order {
+		if err := decoded.checkAncestry(id); err != nil {
+			return err
+		}
+	}
+
+	for _, rootId := range in.Roots {
+		if err := decoded.assertTaskExists(rootId); err != nil {
+			return fmt.Errorf("roots: %w", err)
+		}
+		if parentId, isSubtask := decoded.subtaskOf[rootId]; isSubtask {
+			return fmt.Errorf("root task %v is a subtask of %v", rootId, parentId)
+		}
+		if slices.Contains(decoded.roots, rootId) {
+			return fmt.Errorf("duplicate root task %v", rootId)
+		}
+		decoded.roots = append(decoded.roots, rootId)
+	}
+	isRoot := make(map[task.Id]bool, len(decoded.roots))
+	for _, rootId := range decoded.roots {
+		isRoot[rootId] = true
+	}
+	for _, id := range order {
+		if _, isSubtask := decoded.subtaskOf[id]; !isSubtask && !isRoot[id] {
+			decoded.roots = append(decoded.roots, id)
+		}
+	}
+
+	for blockerId, blockedIds := range in.Blocks {
+		if err := decoded.assertTaskExists(blockerId); err != nil {
+			return fmt.Errorf("blocks: %w", err)
+		}
+		for _, blockedId := range blockedIds {
+			if err := decoded.assertTaskExists(blockedId); err != nil {
+				return fmt.Errorf("blocks of %v: %w", blockerId, err)
+			}
+			if slices.Contains(decoded.blockedBy[blockedId], blockerId) {
+				return fmt.Errorf("task %v blocks %v more than once", blockerId, blockedId)
+			}
+			decoded.blocks[blockerId] = append(decoded.blocks[blockerId], blockedId)
+			decoded.blockedBy[blockedId] = append(decoded.blockedBy[blockedId], blockerId)
+		}
+	}
+	for blockerId, blockedIds := range decoded.blocks {
+		for _, blockedId := range blockedIds {
+			if err := decoded.checkBlockerCycle(blockerId, decoded.subtreeIds(blockedId)); err != nil {
+				return err
+			}
+		}
+	}
+	active := false
+	for i, js := range in.Sessions {
+		// Sessions of deleted tasks are kept, see DeleteTaskWithPolicy.
+		if js.Task == "" {
+			return fmt.Errorf("session %d: missing task", i)
+		}
+		s := Session{TaskId: js.Task, Pomodoro: js.Pomodoro, Legacy: js.Legacy}
+		var err error
+		if s.Start, err = parseJSONTime(js.Start); err != nil || s.Start.IsZero() {
+			return fmt.Errorf("session %d: invalid start %q", i, js.Start)
+		}
+		if s.End, err = parseJSONTime(js.End); err != nil {
+			return fmt.Errorf("session %d: invalid end: %w", i, err)
+		}
+		if s.Active() {
+			if active {
+				return fmt.Errorf("session %d: more than one timer is running", i)
+			}
+			if err := decoded.assertTaskExists(s.TaskId); err != nil {
+				return fmt.Errorf("session %d: timer is running for a deleted task: %w", i, err)
+			}
+			active = true
+		} else if s.End.Before(s.Start) {
+			return fmt.Errorf("session %d ends before it starts", i)
+		}
+		decoded.sessions = append(decoded.sessions, s)
+	}
+	decoded.assignAliases()
+	// This also migrates version 1, which had no time log.
+	decoded.syncTimeInvested(timerNow())
+
+	tree.replace(decoded)
+	return nil
+}
+
+// replace replaces the contents of the TaskTree with those of a decoded one,
+// clearing the history.
+func (tree *TaskTree) replace(decoded *TaskTree) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	tree.tasks = decoded.tasks
+	tree.roots = decoded.roots
+	tree.subtasks = decoded.subtasks
+	tree.subtaskOf = decoded.subtaskOf
+	tree.blocks = decoded.blocks
+	tree.blockedBy = decoded.blockedBy
+	tree.completionPolicy = decoded.completionPolicy
+	tree.sessions = decoded.sessions
+	tree.clearHistory()
+	tree.revision++
+}
+
+// checkAncestry checks that following the parents of a task never leads back to it.
+func (tree *TaskTree) checkAncestry(id task.Id) error {
+	path := []task.Id{id}
+	seen := map[task.Id]bool{id: true}
+	for curr := id; ; {
+		parentId, exists := tree.subtaskOf[curr]
+		if !exists {
+			return nil
+		}
+		path = append([]task.Id{parentId}, path...)
+		if seen[parentId] {
+			// Cut off the tasks below the cycle.
+			end := slices.Index(path[1:], parentId) + 1
+			return tree.cycleError(SubtaskCycle, path[:end+1])
+		}
+		seen[parentId] = true
+		curr = parentId
+	}
+}
@@ -0,0 +1,74 @@
+package tasktree
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalJSONIntegrity(t *testing.T) {
+	// Each document lists the tasks a, b and c, followed by the given fields.
+	const tasks = `"tasks": [{"id": "a", "name": "a"}, {"id": "b", "name": "b"}, {"id": "c", "name": "c"}]`
+	tests := []struct {
+		name   string
+		fields string
+		// wantErr is part of the expected error message. Cycles must be
+		// reported as a CycleError.
+		wantErr string
+	}{
+		{name: "missing version", fields: `"version": 0`, wantErr: "missing schema version"},
+		{name: "newer version", fields: `"version": 3`, wantErr: "unsupported schema version 3"},
+		{name: "dangling parent", fields: `"version": 2, "subtasks": {"x": ["a"]}`, wantErr: "subtasks: task x does not exist"},
+		{name: "dangling subtask", fields: `"version": 2, "subtasks": {"a": ["x"]}`, wantErr: "subtasks of a: task x does not exist"},
+		{name: "two parents", fields: `"version": 2, "subtasks": {"a": ["c"], "b": ["c"]}`, wantErr: "is a subtask of both"},
+		{name: "dangling blocker", fields: `"version": 2, "blocks": {"x": ["a"]}`, wantErr: "blocks: task x does not exist"},
+		{name: "dangling blocked task", fields: `"version": 2, "blocks": {"a": ["x"]}`, wantErr: "blocks of a: task x does not exist"},
+		{name: "duplicate blocker", fields: `"version": 2, "blocks": {"a": ["b", "b"]}`, wantErr: "task a blocks b more than once"},
+		{name: "dangling root", fields: `"version": 2, "roots": ["x"]`, wantErr: "roots: task x does not exist"},
+		{name: "subtask as root", fields: `"version": 2, "roots": ["b"], "subtasks": {"a": ["b"]}`, wantErr: "root task b is a subtask of a"},
+		{name: "subtask cycle", fields: `"version": 2, "subtasks": {"a": ["b"], "b": ["a"]}`, wantErr: "subtask cycle: "},
+		{name: "blocker cycle", fields: `"version": 2, "blocks": {"a": ["b"], "b": ["c"], "c": ["a"]}`, wantErr: "blocker cycle: "},
+		{name: "blocker cycle through a parent", fields: `"version": 2, "subtasks": {"a": ["b"]}, "blocks": {"b": ["a"]}`, wantErr: "blocker cycle: "},
+		{name: "timer of a deleted task", fields: `"version": 2, "sessions": [{"task": "x", "start": "2026-10-18T09:00:00Z"}]`, wantErr: "timer is running for a deleted task"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree, ids := testTreeSpec{tasks: [][2]string{{"keep", ""}}}.build(t)
+			doc := "{" + tasks + ", " + test.fields + "}"
+
+			err := json.Unmarshal([]byte(doc), tree)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+			var cycleErr *CycleError
+			if strings.Contains(test.wantErr, "cycle") && !errors.As(err, &cycleErr) {
+				t.Errorf("got error %v, want a CycleError", err)
+			}
+			if _, exists := tree.GetTask(ids["keep"]); !exists || len(tree.GetAllTasks()) != 1 {
+				t.Error("a failed unmarshal changed the tree")
+			}
+		})
+	}
+
+	t.Run("duplicate id", func(t *testing.T) {
+		doc := `{"version": 2, "tasks": [{"id": "a", "name": "a"}, {"id": "a", "name": "b"}]}`
+		if err := json.Unmarshal([]byte(doc), NewTaskTree()); err == nil || !strings.Contains(err.Error(), "duplicate task id a") {
+			t.Errorf("got error %v, want a duplicate task id", err)
+		}
+	})
+
+	t.Run("sessions of deleted tasks", func(t *testing.T) {
+		doc := `{"version": 2, ` + tasks + `, "sessions": [{"task": "x", "start": "2026-10-18T09:00:00Z", "end": "2026-10-18T10:00:00Z"}]}`
+		tree := NewTaskTree()
+		if err := json.Unmarshal([]byte(doc), tree); err != nil {
+			t.Fatal(err)
+		}
+		if sessions := tree.GetSessions(); len(sessions) != 1 || sessions[0].TaskId != "x" {
+			t.Errorf("got sessions %+v, want the one of deleted task x", sessions)
+		}
+	})
+}
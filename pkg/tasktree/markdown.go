@@ -0,0 +1,224 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"regexp"
+	"strings"
+)
+
+// markdownIndent is the indentation of each level of a Markdown checklist.
+const markdownIndent = "  "
+
+// markdownItem matches a Markdown list item, optionally with a checkbox.
+var markdownItem = regexp.MustCompile(`^[-*+](?:\s+\[([ xX])\])?(?:\s+(.*))?$`)
+
+// MarshalMarkdown encodes a TaskTree as a nested Markdown checklist, one item
+// per task such as "- [x] write report #work !high", with subtasks indented
+// under it after its description. Only the name, completion, tags, priority
+// and description of tasks are kept. Words of names and lines of descriptions
+// that would be read back as something else are escaped with a backslash.
+func (tree *TaskTree) MarshalMarkdown() ([]byte, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	var sb strings.Builder
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			t := tree.tasks[id]
+			indent := strings.Repeat(markdownIndent, len(tree.ancestorIds(id)))
+
+			checkbox := "[ ]"
+			if t.Completed {
+				checkbox = "[x]"
+			}
+			words := util.Map(strings.Split(t.Name, " "), escapeMarkdownWord)
+			for _, tag := range t.Tags {
+				words = append(words, "#"+string(tag))
+			}
+			if t.Priority != task.Default {
+				words = append(words, "!"+t.Priority.String())
+			}
+			fmt.Fprintf(&sb, "%v- %v %v\n", indent, checkbox, strings.Join(words, " "))
+
+			// Blank lines around the description would be dropped when reading
+			// it back.
+			description := strings.Trim(t.Description, "\n")
+			if strings.TrimSpace(description) == "" {
+				continue
+			}
+			for _, line := range strings.Split(description, "\n") {
+				if strings.TrimSpace(line) == "" {
+					sb.WriteString("\n")
+					continue
+				}
+				trimmed := strings.TrimLeft(line, " \t")
+				if strings.HasPrefix(trimmed, `\`) || markdownItem.MatchString(trimmed) {
+					line = line[:len(line)-len(trimmed)] + `\` + trimmed
+				}
+				sb.WriteString(indent + markdownIndent + line + "\n")
+			}
+		}
+	}
+	return []byte(sb.String()), nil
+}
+
+// escapeMarkdownWord escapes a word of a task name that would be read back as
+// a tag or priority.
+func escapeMarkdownWord(word string) string {
+	if strings.HasPrefix(word, "#") || strings.HasPrefix(word, "!") || strings.HasPrefix(word, `\`) {
+		return `\` + word
+	}
+	return word
+}
+
+// UnmarshalMarkdown decodes a TaskTree from a nested Markdown checklist,
+// replacing the contents of the TaskTree. Each list item becomes a task, a
+// subtask of the item it is indented under, completed if it is checked.
+// Words of an item such as "#work" become tags and words such as "!high" set
+// the priority. Lines indented under an item that aren't list items make up
+// its description. Lines outside of the list, such as headings, are ignored.
+func (tree *TaskTree) UnmarshalMarkdown(data []byte) error {
+	decoded := NewTaskTree()
+
+	type level struct {
+		indent int
+		id     task.Id
+	}
+	var stack []level
+	descriptions := make(map[task.Id][]string)
+	blankLines := 0
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blankLines++
+			continue
+		}
+		indent, content := markdownIndentation(line)
+
+		if match := markdownItem.FindStringSubmatch(content); match != nil {
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+			var parentId task.Id
+			if len(stack) > 0 {
+				parentId = stack[len(stack)-1].id
+			}
+
+			t, err := parseMarkdownItem(match[2])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", i+1, err)
+			}
+			t.Completed = match[1] == "x" || match[1] == "X"
+			if err := decoded.addTask(t, parentId); err != nil {
+				return fmt.Errorf("line %d: %w", i+1, err)
+			}
+			stack = append(stack, level{indent: indent, id: t.Id})
+			blankLines = 0
+			continue
+		}
+
+		// Other lines belong to the closest item they are indented under.
+		owner := -1
+		for j := len(stack) - 1; j >= 0; j-- {
+			if stack[j].indent < indent {
+				owner = j
+				break
+			}
+		}
+		if owner == -1 {
+			stack = nil
+			blankLines = 0
+			continue
+		}
+		stack = stack[:owner+1]
+
+		id := stack[owner].id
+		if len(descriptions[id]) > 0 {
+			for ; blankLines > 0; blankLines-- {
+				descriptions[id] = append(descriptions[id], "")
+			}
+		}
+		blankLines = 0
+
+		// Keep indentation beyond that of the description itself.
+		text := trimIndentation(line, stack[owner].indent+len(markdownIndent))
+		if trimmed := strings.TrimLeft(text, " \t"); strings.HasPrefix(trimmed, `\`) {
+			text = text[:len(text)-len(trimmed)] + trimmed[1:]
+		}
+		descriptions[id] = append(descriptions[id], text)
+	}
+
+	for id, description := range descriptions {
+		t := decoded.tasks[id]
+		t.Description = strings.Join(description, "\n")
+		decoded.tasks[id] = t
+	}
+	tree.replace(decoded)
+	return nil
+}
+
+// markdownIndentation splits a line into the width of its indentation, with
+// tabs counting as 4 spaces, and its content.
+func markdownIndentation(line string) (int, string) {
+	width := 0
+	for i, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width, line[i:]
+		}
+	}
+	return width, ""
+}
+
+// trimIndentation removes up to a width of indentation from a line.
+func trimIndentation(line string, width int) string {
+	for i, r := range line {
+		if width <= 0 || r != ' ' && r != '\t' {
+			return line[i:]
+		}
+		if r == '\t' {
+			width -= 4
+		} else {
+			width--
+		}
+	}
+	return ""
+}
+
+// parseMarkdownItem parses the text of a checklist item into a new task.
+func parseMarkdownItem(text string) (task.Task, error) {
+	t := task.Task{Id: task.NewId()}
+	var name []string
+	for _, word := range strings.Split(text, " ") {
+		if strings.HasPrefix(word, `\`) {
+			name = append(name, word[1:])
+			continue
+		}
+		if tag, isTag := strings.CutPrefix(word, "#"); isTag && tag != "" {
+			if !util.Contains(t.Tags, task.Tag(tag)) {
+				t.Tags = append(t.Tags, task.Tag(tag))
+			}
+			continue
+		}
+		if priorityName, isPriority := strings.CutPrefix(word, "!"); isPriority {
+			if priority, err := task.ParsePriority(priorityName); err == nil {
+				t.Priority = priority
+				continue
+			}
+		}
+		name = append(name, word)
+	}
+
+	t.Name = strings.TrimSpace(strings.Join(name, " "))
+	if t.Name == "" {
+		return task.Task{}, fmt.Errorf("item %q has no name", text)
+	}
+	return t, nil
+}
@@ -0,0 +1,186 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+	"testing"
+)
+
+func markdownFields(t task.Task) string {
+	return fmt.Sprintf(
+		"name=%q completed=%v priority=%v tags=%v description=%q",
+		t.Name, t.Completed, t.Priority, strings.Join(util.Map(t.Tags, func(tag task.Tag) string { return string(tag) }), ","), t.Description,
+	)
+}
+
+// roundTripMarkdown exports a tree, imports the result and exports it again,
+// failing if the two exports or the two trees differ.
+func roundTripMarkdown(t *testing.T, tree *TaskTree) string {
+	t.Helper()
+	first, err := tree.MarshalMarkdown()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	imported := NewTaskTree()
+	if err := imported.UnmarshalMarkdown(first); err != nil {
+		t.Fatalf("failed to import %q: %v", first, err)
+	}
+	second, err := imported.MarshalMarkdown()
+	if err != nil {
+		t.Fatalf("failed to export the imported tree: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("export changed after import:\nfirst:\n%v\nsecond:\n%v", first, second)
+	}
+	if want, got := outline(tree, markdownFields), outline(imported, markdownFields); want != got {
+		t.Errorf("imported tree differs:\nwant:\n%v\ngot:\n%v", want, got)
+	}
+	return string(first)
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	withDescription := func(name string, description string) task.Task {
+		tsk := newTestTask(name)
+		tsk.Description = description
+		return tsk
+	}
+
+	tests := []struct {
+		name  string
+		build func(t *testing.T, tree *TaskTree)
+		want  string
+	}{
+		{
+			name: "nested checklist",
+			build: func(t *testing.T, tree *TaskTree) {
+				release := newTestTask("release")
+				release.Tags = []task.Tag{"work"}
+				release.Priority = task.High
+				release = mustAdd(t, tree, "", release)
+				changelog := newTestTask("write changelog")
+				changelog.Completed = true
+				mustAdd(t, tree, release.Id, changelog)
+				mustAdd(t, tree, "", newTestTask("buy milk"))
+			},
+			want: "- [ ] release #work !high\n  - [x] write changelog\n- [ ] buy milk\n",
+		},
+		{
+			name: "name words that look like tags and priorities",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", newTestTask("fix #42 before !important"))
+				mustAdd(t, tree, "", newTestTask("#hashtag ! and #"))
+				mustAdd(t, tree, "", newTestTask("!urgent really"))
+			},
+			want: `- [ ] fix \#42 before \!important` + "\n" +
+				`- [ ] \#hashtag \! and \#` + "\n" +
+				`- [ ] \!urgent really` + "\n",
+		},
+		{
+			name: "name words with backslashes",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", newTestTask(`\#not-a-tag C:\path \\share back\slash`))
+			},
+			want: `- [ ] \\#not-a-tag C:\path \\\share back\slash` + "\n",
+		},
+		{
+			name: "names with extra spaces",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", newTestTask("two  spaces"))
+			},
+			want: "- [ ] two  spaces\n",
+		},
+		{
+			name: "description lines that look like list items",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", withDescription("plan", "steps:\n- first\n* second\n+ third\n- [ ] fourth\n  - nested\n-"))
+			},
+			want: "- [ ] plan\n  steps:\n  \\- first\n  \\* second\n  \\+ third\n  \\- [ ] fourth\n    \\- nested\n  \\-\n",
+		},
+		{
+			name: "description lines with backslashes",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", withDescription("paths", `\- escaped already`+"\n"+`C:\windows`+"\n"+`  \\share`))
+			},
+			want: "- [ ] paths\n  \\\\- escaped already\n  C:\\windows\n    \\\\\\share\n",
+		},
+		{
+			name: "description lines that don't look like list items",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", withDescription("notes", "-not an item\n#heading\n1. numbered"))
+			},
+			want: "- [ ] notes\n  -not an item\n  #heading\n  1. numbered\n",
+		},
+		{
+			name: "descriptions with blank lines, followed by subtasks",
+			build: func(t *testing.T, tree *TaskTree) {
+				parent := mustAdd(t, tree, "", withDescription("parent", "first paragraph\n\n\nsecond paragraph"))
+				mustAdd(t, tree, parent.Id, withDescription("child", "- looks like a sibling"))
+			},
+			want: "- [ ] parent\n  first paragraph\n\n\n  second paragraph\n  - [ ] child\n    \\- looks like a sibling\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewTaskTree()
+			tt.build(t, tree)
+			if got := roundTripMarkdown(t, tree); got != tt.want {
+				t.Errorf("got export:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "headings and text outside of the list are ignored",
+			input: "# Tasks\n\nSome notes.\n\n- [X] done #home\n* [ ] open\n",
+			want: `name="done" completed=true priority=default tags=home description=""` + "\n" +
+				`name="open" completed=false priority=default tags= description=""`,
+		},
+		{
+			name:  "items without checkboxes",
+			input: "- plain\n+ plus\n",
+			want: `name="plain" completed=false priority=default tags= description=""` + "\n" +
+				`name="plus" completed=false priority=default tags= description=""`,
+		},
+		{
+			name:  "unknown priorities stay in the name",
+			input: "- [ ] wow !!! !whenever !low\n",
+			want:  `name="wow !!! !whenever" completed=false priority=low tags= description=""`,
+		},
+		{
+			name:  "tab indentation",
+			input: "- [ ] parent\n\t- [ ] child\n\t\tdescription\n",
+			want: `name="parent" completed=false priority=default tags= description=""` + "\n" +
+				`  name="child" completed=false priority=default tags= description="description"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewTaskTree()
+			if err := tree.UnmarshalMarkdown([]byte(tt.input)); err != nil {
+				t.Fatalf("failed to import: %v", err)
+			}
+			if got := outline(tree, markdownFields); got != tt.want {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalMarkdownErrors(t *testing.T) {
+	for _, input := range []string{"- [ ]\n", "- [x] #tag !high\n", "-\n"} {
+		if err := NewTaskTree().UnmarshalMarkdown([]byte(input)); err == nil {
+			t.Errorf("importing %q succeeded, expected an error", input)
+		}
+	}
+}
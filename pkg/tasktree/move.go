@@ -0,0 +1,108 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+)
+
+// MoveTask moves a task along with all of its subtasks to a new parent, or to
+// the root tasks if newParentId is empty. The task is placed at the given index
+// among its new siblings; a negative or out of range position places it last.
+// Moving a task within its current parent reorders it. Returns a *CycleError
+// if the task would become its own ancestor, or if a blocker inherited from
+// the new parent would end up transitively blocking itself.
+func (tree *TaskTree) MoveTask(id task.Id, newParentId task.Id, position int) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	return tree.moveTask(id, newParentId, position)
+}
+
+// moveTask implements MoveTask. The caller must hold the write lock.
+func (tree *TaskTree) moveTask(id task.Id, newParentId task.Id, position int) error {
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+	if newParentId != "" {
+		if err := tree.assertTaskExists(newParentId); err != nil {
+			return err
+		}
+	}
+
+	parentId := tree.subtaskOf[id]
+	index := slices.Index(tree.siblings(parentId), id)
+	if parentId == newParentId {
+		// The task is moved within its siblings, so the last index is one
+		// lower than usual.
+		if position < 0 || position >= len(tree.siblings(parentId)) {
+			position = len(tree.siblings(parentId)) - 1
+		}
+		if position == index {
+			return nil
+		}
+	}
+
+	return tree.mutate("move task", func() error {
+		tree.detach(id)
+		// Checking for cycles once the task is detached means that the
+		// blockers it inherits from its old ancestors aren't considered.
+		if newParentId != "" {
+			if err := tree.checkSubtaskCycle(newParentId, id); err != nil {
+				return err
+			}
+		}
+		tree.attach(id, newParentId, position)
+		return nil
+	})
+}
+
+// IndentTask makes a task the last subtask of the sibling right before it.
+func (tree *TaskTree) IndentTask(id task.Id) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+
+	siblings := tree.siblings(tree.subtaskOf[id])
+	index := slices.Index(siblings, id)
+	if index == 0 {
+		return fmt.Errorf("task %v has no previous sibling to become a subtask of", tree.label(id))
+	}
+
+	return tree.moveTask(id, siblings[index-1], -1)
+}
+
+// OutdentTask makes a task a sibling of its parent, placed right after it.
+func (tree *TaskTree) OutdentTask(id task.Id) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+
+	parentId, isSubtask := tree.subtaskOf[id]
+	if !isSubtask {
+		return fmt.Errorf("task %v is already a root task", tree.label(id))
+	}
+
+	grandparentId := tree.subtaskOf[parentId]
+	return tree.moveTask(id, grandparentId, slices.Index(tree.siblings(grandparentId), parentId)+1)
+}
+
+// GetPosition returns the parent of a task (empty for root tasks) and the
+// index of the task among its siblings.
+func (tree *TaskTree) GetPosition(id task.Id) (parentId task.Id, index int, err error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return "", 0, err
+	}
+
+	parentId = tree.subtaskOf[id]
+	return parentId, slices.Index(tree.siblings(parentId), id), nil
+}
@@ -0,0 +1,140 @@
+package tasktree
+
+import (
+	"errors"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"strings"
+	"testing"
+)
+
+func TestMoveTask(t *testing.T) {
+	spec := testTreeSpec{
+		tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"a2", "a"}, {"b", ""}, {"c", ""}},
+		blockers: [][2]string{{"c", "a"}},
+	}
+	initial := "a\n  a1\n    a11\n  a2\nb\nc"
+	name := func(t task.Task) string { return t.Name }
+
+	tests := []struct {
+		name string
+		move func(tree *TaskTree, ids map[string]task.Id) error
+		// want is the outline of the tree after the move. wantErr is part of
+		// the error message if the move fails instead.
+		want    string
+		wantErr string
+	}{
+		{
+			name: "to another parent",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a1"], ids["b"], -1) },
+			want: "a\n  a2\nb\n  a1\n    a11\nc",
+		},
+		{
+			name: "to the roots",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a11"], "", 1) },
+			want: "a\n  a1\n  a2\na11\nb\nc",
+		},
+		{
+			name: "within its siblings",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a2"], ids["a"], 0) },
+			want: "a\n  a2\n  a1\n    a11\nb\nc",
+		},
+		{
+			name: "out of range position",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a"], "", 10) },
+			want: "b\nc\na\n  a1\n    a11\n  a2",
+		},
+		{
+			name:    "under itself",
+			move:    func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a"], ids["a"], -1) },
+			wantErr: "subtask cycle: ",
+		},
+		{
+			name:    "under its own descendant",
+			move:    func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["a"], ids["a11"], -1) },
+			wantErr: "subtask cycle: ",
+		},
+		{
+			// c would inherit itself as a blocker from a.
+			name:    "under a task it blocks",
+			move:    func(tree *TaskTree, ids map[string]task.Id) error { return tree.MoveTask(ids["c"], ids["a2"], -1) },
+			wantErr: "blocker cycle: ",
+		},
+		{
+			name: "indent",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.IndentTask(ids["a2"]) },
+			want: "a\n  a1\n    a11\n    a2\nb\nc",
+		},
+		{
+			name: "indent a root task",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.IndentTask(ids["b"]) },
+			want: "a\n  a1\n    a11\n  a2\n  b\nc",
+		},
+		{
+			name:    "indent the first sibling",
+			move:    func(tree *TaskTree, ids map[string]task.Id) error { return tree.IndentTask(ids["a1"]) },
+			wantErr: "has no previous sibling",
+		},
+		{
+			name:    "indent the first root task",
+			move:    func(tree *TaskTree, ids map[string]task.Id) error { return tree.IndentTask(ids["a"]) },
+			wantErr: "has no previous sibling",
+		},
+		{
+			name: "outdent",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.OutdentTask(ids["a11"]) },
+			want: "a\n  a1\n  a11\n  a2\nb\nc",
+		},
+		{
+			name: "outdent to the roots",
+			move: func(tree *TaskTree, ids map[string]task.Id) error { return tree.OutdentTask(ids["a1"]) },
+			want: "a\n  a2\na1\n  a11\nb\nc",
+		},
+		{
+			name:    "outdent a root task",
+			move:    func(tree *TaskTree, ids map[string]task.Id) error { return tree.OutdentTask(ids["b"]) },
+			wantErr: "is already a root task",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree, ids := spec.build(t)
+			err := test.move(tree, ids)
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				var cycleErr *CycleError
+				if strings.Contains(test.wantErr, "cycle") && !errors.As(err, &cycleErr) {
+					t.Errorf("got error %v, want a CycleError", err)
+				}
+				if got := outline(tree, name); got != initial {
+					t.Errorf("got\n%v\nafter a failed move, want\n%v", got, initial)
+				}
+				if undone, _ := tree.Undo(); undone == "move task" {
+					t.Error("a failed move was recorded in the history")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(tree, name); got != test.want {
+				t.Errorf("got\n%v\nwant\n%v", got, test.want)
+			}
+			if _, err := tree.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(tree, name); got != initial {
+				t.Errorf("got\n%v\nafter undo, want\n%v", got, initial)
+			}
+			if _, err := tree.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := outline(tree, name); got != test.want {
+				t.Errorf("got\n%v\nafter redo, want\n%v", got, test.want)
+			}
+		})
+	}
+}
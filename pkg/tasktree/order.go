@@ -0,0 +1,153 @@
+package tasktree
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"strings"
+)
+
+// A SortKey is a field siblings can be sorted by.
+type SortKey int
+
+const (
+	// SortByPriority sorts the most important tasks first. Tasks with the
+	// default priority are treated as having normal priority.
+	SortByPriority SortKey = iota
+	// SortByName sorts tasks alphabetically, ignoring case.
+	SortByName
+	// SortByEstimate sorts the quickest tasks first. Tasks without an estimate come last.
+	SortByEstimate
+)
+
+func (k SortKey) String() string {
+	switch k {
+	case SortByPriority:
+		return "priority"
+	case SortByName:
+		return "name"
+	case SortByEstimate:
+		return "estimate"
+	default:
+		return fmt.Sprintf("SortKey(%d)", int(k))
+	}
+}
+
+// ParseSortKey parses a SortKey from its name (e.g. "priority").
+func ParseSortKey(name string) (SortKey, error) {
+	for k := SortByPriority; k <= SortByEstimate; k++ {
+		if strings.EqualFold(name, k.String()) {
+			return k, nil
+		}
+	}
+	return SortByPriority, fmt.Errorf("unknown sort key %q, expected one of: priority, name, estimate", name)
+}
+
+// compare orders two tasks by a SortKey.
+func (k SortKey) compare(a, b task.Task) int {
+	switch k {
+	case SortByPriority:
+		return cmp.Compare(priorityRank(a.Priority), priorityRank(b.Priority))
+	case SortByName:
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case SortByEstimate:
+		if (a.EstimatedTime == 0) != (b.EstimatedTime == 0) {
+			// Tasks without an estimate come last.
+			if a.EstimatedTime == 0 {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.EstimatedTime, b.EstimatedTime)
+	default:
+		return 0
+	}
+}
+
+func priorityRank(p task.Priority) task.Priority {
+	if p == task.Default {
+		return task.Normal
+	}
+	return p
+}
+
+// MoveUp swaps a task with the sibling right before it.
+func (tree *TaskTree) MoveUp(id task.Id) error {
+	return tree.shift(id, -1)
+}
+
+// MoveDown swaps a task with the sibling right after it.
+func (tree *TaskTree) MoveDown(id task.Id) error {
+	return tree.shift(id, 1)
+}
+
+// shift moves a task by offset positions among its siblings.
+func (tree *TaskTree) shift(id task.Id, offset int) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+
+	parentId := tree.subtaskOf[id]
+	position := slices.Index(tree.siblings(parentId), id) + offset
+	if position < 0 {
+		return fmt.Errorf("task %v is already the first of its siblings", tree.label(id))
+	} else if position >= len(tree.siblings(parentId)) {
+		return fmt.Errorf("task %v is already the last of its siblings", tree.label(id))
+	}
+
+	return tree.moveTask(id, parentId, position)
+}
+
+// MoveToTop makes a task the first of its siblings.
+func (tree *TaskTree) MoveToTop(id task.Id) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+
+	return tree.moveTask(id, tree.subtaskOf[id], 0)
+}
+
+// MoveToBottom makes a task the last of its siblings.
+func (tree *TaskTree) MoveToBottom(id task.Id) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return err
+	}
+
+	return tree.moveTask(id, tree.subtaskOf[id], -1)
+}
+
+// SortSubtasks sorts the subtasks of a task, or the root tasks if parentId is
+// empty. The sort is stable, so tasks that compare equal keep their order.
+func (tree *TaskTree) SortSubtasks(parentId task.Id, key SortKey) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if parentId != "" {
+		if err := tree.assertTaskExists(parentId); err != nil {
+			return err
+		}
+	}
+
+	sorted := slices.Clone(tree.siblings(parentId))
+	slices.SortStableFunc(sorted, func(a, b task.Id) int {
+		return key.compare(tree.tasks[a], tree.tasks[b])
+	})
+	if slices.Equal(sorted, tree.siblings(parentId)) {
+		return nil
+	}
+
+	return tree.mutate("sort subtasks", func() error {
+		tree.reorder(parentId, sorted)
+		return nil
+	})
+}
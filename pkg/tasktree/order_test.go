@@ -0,0 +1,131 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShiftSiblings(t *testing.T) {
+	tests := []struct {
+		name  string
+		shift func(tree *TaskTree, id task.Id) error
+		task  string
+		// want are the subtasks of p after the shift, and wantErr part of
+		// the error message if it fails instead.
+		want    []string
+		wantErr string
+	}{
+		{name: "up", shift: (*TaskTree).MoveUp, task: "y", want: []string{"w", "y", "x", "z"}},
+		{name: "up the first", shift: (*TaskTree).MoveUp, task: "w", wantErr: "already the first"},
+		{name: "down", shift: (*TaskTree).MoveDown, task: "x", want: []string{"w", "y", "x", "z"}},
+		{name: "down the last", shift: (*TaskTree).MoveDown, task: "z", wantErr: "already the last"},
+		{name: "to the top", shift: (*TaskTree).MoveToTop, task: "y", want: []string{"y", "w", "x", "z"}},
+		{name: "to the bottom", shift: (*TaskTree).MoveToBottom, task: "w", want: []string{"x", "y", "z", "w"}},
+		{name: "the top to the top", shift: (*TaskTree).MoveToTop, task: "w", want: []string{"w", "x", "y", "z"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree, ids := testTreeSpec{tasks: [][2]string{{"p", ""}, {"w", "p"}, {"x", "p"}, {"y", "p"}, {"z", "p"}, {"q", ""}}}.build(t)
+			err := test.shift(tree, ids[test.task])
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(tree, tree.subtasks[ids["p"]]); !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			if got := names(tree, tree.roots); !slices.Equal(got, []string{"p", "q"}) {
+				t.Errorf("got roots %v, want them unchanged", got)
+			}
+		})
+	}
+}
+
+func TestSortSubtasks(t *testing.T) {
+	tree, ids := testTreeSpec{tasks: [][2]string{{"p", ""}, {"b", "p"}, {"C", "p"}, {"a", "p"}, {"d", "p"}, {"e", "p"}}}.build(t)
+	for name, fields := range map[string]struct {
+		priority task.Priority
+		estimate time.Duration
+	}{
+		"b": {task.High, 2 * time.Hour},
+		"C": {task.Default, 0},
+		"a": {task.Normal, time.Hour},
+		"d": {task.Urgent, time.Hour},
+		"e": {task.Low, 2 * time.Hour},
+	} {
+		tsk, _ := tree.GetTask(ids[name])
+		tsk.Priority, tsk.EstimatedTime = fields.priority, fields.estimate
+		if err := tree.UpdateTask(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(step string, want []string) {
+		t.Helper()
+		if got := names(tree, tree.subtasks[ids["p"]]); !slices.Equal(got, want) {
+			t.Errorf("%v: got %v, want %v", step, got, want)
+		}
+	}
+	initial := []string{"b", "C", "a", "d", "e"}
+	// Ties keep their order: C has the default priority, which sorts like
+	// the normal priority of a, and a and d as well as b and e have the same
+	// estimates.
+	byPriority := []string{"d", "b", "C", "a", "e"}
+	byEstimate := []string{"d", "a", "b", "e", "C"}
+	check("initial", initial)
+
+	if err := tree.SortSubtasks(ids["p"], SortByName); err != nil {
+		t.Fatal(err)
+	}
+	check("by name", []string{"a", "b", "C", "d", "e"})
+	if _, err := tree.Undo(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tree.SortSubtasks(ids["p"], SortByPriority); err != nil {
+		t.Fatal(err)
+	}
+	check("by priority", byPriority)
+	// Sorting again changes nothing and isn't recorded in the history.
+	revision := tree.Revision()
+	if err := tree.SortSubtasks(ids["p"], SortByPriority); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Revision() != revision {
+		t.Error("sorting sorted subtasks changed the tree")
+	}
+	if err := tree.SortSubtasks(ids["p"], SortByEstimate); err != nil {
+		t.Fatal(err)
+	}
+	check("by estimate after priority", byEstimate)
+
+	for _, step := range []struct {
+		name string
+		do   func() (string, error)
+		want []string
+	}{
+		{"undo", tree.Undo, byPriority},
+		{"undo", tree.Undo, initial},
+		{"redo", tree.Redo, byPriority},
+		{"redo", tree.Redo, byEstimate},
+	} {
+		if _, err := step.do(); err != nil {
+			t.Fatal(err)
+		}
+		check(step.name, step.want)
+	}
+
+	if err := tree.SortSubtasks("", SortByName); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.SortSubtasks(task.NewId(), SortByName); err == nil {
+		t.Error("expected an error sorting the subtasks of a missing task")
+	}
+}
@@ -0,0 +1,82 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"time"
+)
+
+// recur creates the next instance of a recurring task that was just
+// completed, placed right after it among its siblings. The recurrence moves
+// to the new instance, so that reopening and completing the previous instance
+// again doesn't create another one. The subtasks are copied along with it if
+// the recurrence includes them. Returns the new instance. The caller must hold
+// the write lock and be inside of mutate.
+func (tree *TaskTree) recur(id task.Id, now time.Time) task.Task {
+	t := tree.tasks[id]
+	rule := t.Recurrence
+
+	// The dates of the instance are moved by as many days as it takes the
+	// due date, or the start date if there is none, to get to the next one.
+	// Monthly rules are anchored to that day so that the next instances
+	// don't drift after shorter months.
+	anchor := t.RecurrenceAnchor()
+	var next time.Time
+	if rule.AfterCompletion || anchor.IsZero() {
+		rule = rule.Anchored(now)
+		next = rule.NextDate(now)
+	} else {
+		rule = rule.Anchored(anchor)
+		next = rule.NextDate(anchor)
+		// Skip the instances that were missed while the task was overdue.
+		for next.Before(task.StartOfDay(now)) {
+			next = rule.NextDate(next)
+		}
+	}
+	shift := func(date time.Time) time.Time {
+		if date.IsZero() {
+			return date
+		}
+		return date.AddDate(0, 0, task.DaysBetween(anchor, next))
+	}
+
+	previous := t
+	previous.Recurrence = task.Recurrence{}
+	tree.setTask(previous)
+
+	instance := tree.newInstance(t, shift)
+	if anchor.IsZero() {
+		instance.Due = next
+	}
+	instance.Recurrence = rule
+	parentId := tree.subtaskOf[id]
+	tree.setTask(instance)
+	tree.attach(instance.Id, parentId, slices.Index(tree.siblings(parentId), id)+1)
+
+	if rule.Subtree {
+		newIds := map[task.Id]task.Id{id: instance.Id}
+		for _, descendantId := range tree.subtreeIds(id)[1:] {
+			clone := tree.newInstance(tree.tasks[descendantId], shift)
+			newIds[descendantId] = clone.Id
+			tree.setTask(clone)
+			tree.attach(clone.Id, newIds[tree.subtaskOf[descendantId]], -1)
+		}
+	}
+
+	return instance
+}
+
+// newInstance copies a task under a fresh id and alias, reset to incomplete
+// with no time invested and with its dates moved by shift. The caller must
+// hold the lock.
+func (tree *TaskTree) newInstance(t task.Task, shift func(time.Time) time.Time) task.Task {
+	t.Id = task.NewId()
+	t.Alias = tree.nextAlias()
+	t.Completed = false
+	t.CompletedAt = time.Time{}
+	t.TimeInvested = 0
+	t.Tags = slices.Clone(t.Tags)
+	t.Due = shift(t.Due)
+	t.Start = shift(t.Start)
+	return t
+}
@@ -0,0 +1,156 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"testing"
+	"time"
+)
+
+// recurAt creates the next instance of a recurring task as if it had been
+// completed at a given time.
+func recurAt(t *testing.T, tree *TaskTree, id task.Id, now time.Time) task.Task {
+	t.Helper()
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	var instance task.Task
+	err := tree.mutate("complete task", func() error {
+		tree.setCompleted(id, true)
+		instance = tree.recur(id, now)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return instance
+}
+
+// date parses a date in the local time zone, failing the test if it's invalid.
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestRecur(t *testing.T) {
+	mon, thu := time.Monday, time.Thursday
+	tests := []struct {
+		name  string
+		rule  task.Recurrence
+		due   string
+		start string
+		// now returns the time each instance is completed at, given its due
+		// date.
+		now  func(due time.Time) time.Time
+		want []string
+	}{
+		{
+			name: "monthly from the 31st",
+			rule: task.Recurrence{Frequency: task.Monthly},
+			due:  "2026-01-31",
+			want: []string{"2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"},
+		},
+		{
+			name: "monthly through a leap february",
+			rule: task.Recurrence{Frequency: task.Monthly},
+			due:  "2028-01-30",
+			want: []string{"2028-02-29", "2028-03-30", "2028-04-30"},
+		},
+		{
+			name:  "monthly from the start date",
+			rule:  task.Recurrence{Frequency: task.Monthly},
+			start: "2026-01-31",
+			want:  []string{"", "", ""},
+		},
+		{
+			name: "weekly on mon,thu",
+			rule: task.Recurrence{Frequency: task.Weekly, Weekdays: []time.Weekday{mon, thu}},
+			due:  "2026-03-09",
+			want: []string{"2026-03-12", "2026-03-16", "2026-03-19"},
+		},
+		{
+			name: "weekly when overdue",
+			rule: task.Recurrence{Frequency: task.Weekly},
+			due:  "2026-03-02",
+			now:  func(due time.Time) time.Time { return due.AddDate(0, 0, 10) },
+			want: []string{"2026-03-16", "2026-03-30"},
+		},
+		{
+			name: "monthly after completion",
+			rule: task.Recurrence{Frequency: task.Monthly, AfterCompletion: true},
+			due:  "2026-01-31",
+			now:  func(due time.Time) time.Time { return due.AddDate(0, 0, 2) },
+			want: []string{"2026-03-02", "2026-04-04"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := NewTaskTree()
+			tsk := newTestTask("rent")
+			tsk.Recurrence = test.rule
+			if test.due != "" {
+				tsk.Due = date(t, test.due)
+			}
+			if test.start != "" {
+				tsk.Start = date(t, test.start)
+			}
+			current := mustAdd(t, tree, "", tsk)
+
+			for _, want := range test.want {
+				now := current.RecurrenceAnchor()
+				if test.now != nil {
+					now = test.now(now)
+				}
+				current = recurAt(t, tree, current.Id, now)
+				if got := current.Due.Format(time.DateOnly); want != "" && got != want {
+					t.Errorf("got due date %v, want %v", got, want)
+				}
+			}
+			if test.start != "" {
+				// 2026-01-31 moved by 3 months.
+				if got := current.Start.Format(time.DateOnly); got != "2026-04-30" {
+					t.Errorf("got start date %v, want 2026-04-30", got)
+				}
+			}
+			if len(tree.roots) != len(test.want)+1 {
+				t.Errorf("got %v tasks, want %v", len(tree.roots), len(test.want)+1)
+			}
+		})
+	}
+}
+
+func TestRecurSubtree(t *testing.T) {
+	tree := NewTaskTree()
+	parent := newTestTask("clean")
+	parent.Due = date(t, "2026-01-31")
+	parent.Recurrence = task.Recurrence{Frequency: task.Monthly, Subtree: true}
+	parent = mustAdd(t, tree, "", parent)
+	child := newTestTask("kitchen")
+	child.Due = date(t, "2026-01-30")
+	mustAdd(t, tree, parent.Id, child)
+
+	instance := recurAt(t, tree, parent.Id, parent.Due)
+	if got := instance.Recurrence.MonthDay; got != 31 {
+		t.Errorf("got month day %v, want the rule anchored to 31", got)
+	}
+	fields := func(t task.Task) string {
+		return t.Name + " " + t.Due.Format(time.DateOnly) + map[bool]string{true: " x"}[t.Completed]
+	}
+	want := "clean 2026-01-31 x\n  kitchen 2026-01-30\nclean 2026-02-28\n  kitchen 2026-02-27"
+	if got := outline(tree, fields); got != want {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	// Undoing the completion removes the next instance along with its
+	// subtasks.
+	if _, err := tree.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	want = "clean 2026-01-31\n  kitchen 2026-01-30"
+	if got := outline(tree, fields); got != want {
+		t.Errorf("after undo got\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,238 @@
+package tasktree
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A ReportGrouping decides how a Report groups logged time.
+type ReportGrouping int
+
+const (
+	// GroupByDay groups time by the day it was logged on.
+	GroupByDay ReportGrouping = iota
+	// GroupByWeek groups time by the week, starting on Monday, it was logged in.
+	GroupByWeek
+	// GroupByTag groups time by the tags of the task it was logged on. Time
+	// logged on a task with several tags counts towards each of them.
+	GroupByTag
+	// GroupByPriority groups time by the effective priority of the task it
+	// was logged on, i.e. that of its closest prioritized ancestor if it has
+	// the default priority.
+	GroupByPriority
+	// GroupBySubtree groups time by the root task of the task it was logged on.
+	GroupBySubtree
+)
+
+func (g ReportGrouping) String() string {
+	switch g {
+	case GroupByDay:
+		return "day"
+	case GroupByWeek:
+		return "week"
+	case GroupByTag:
+		return "tag"
+	case GroupByPriority:
+		return "priority"
+	case GroupBySubtree:
+		return "subtree"
+	default:
+		return fmt.Sprintf("ReportGrouping(%d)", int(g))
+	}
+}
+
+// ParseReportGrouping parses a ReportGrouping from its name (e.g. "tag").
+func ParseReportGrouping(name string) (ReportGrouping, error) {
+	for g := GroupByDay; g <= GroupBySubtree; g++ {
+		if strings.EqualFold(name, g.String()) {
+			return g, nil
+		}
+	}
+	return GroupByDay, fmt.Errorf("unknown report grouping %q, expected one of: day, week, tag, priority, subtree", name)
+}
+
+// untaggedKey is the key of the row of a GroupByTag report for tasks without tags.
+const untaggedKey = "(untagged)"
+
+// deletedKey is the key of the row of a GroupByTag, GroupByPriority or
+// GroupBySubtree report for time logged on deleted tasks.
+const deletedKey = "(deleted tasks)"
+
+// A ReportRow is the time logged in one group of a Report, along with the
+// estimated and total invested time of the estimated tasks it was logged on.
+type ReportRow struct {
+	// Key names the group: a date such as "2026-10-18" (the Monday of the
+	// week for GroupByWeek), a tag such as "#work", a priority, or the alias
+	// and name of a root task. Time logged on deleted tasks is grouped under
+	// "(deleted tasks)" unless grouped by date.
+	Key string
+	// Logged is the time logged in the group within the range of the report.
+	Logged time.Duration
+	// Tasks is the number of tasks time was logged on.
+	Tasks int
+	// Estimate is the total estimated time of those tasks that have an estimate.
+	Estimate time.Duration
+	// Invested is the total time ever invested in the tasks that have an
+	// estimate, including outside of the range of the report, so that it
+	// compares to Estimate.
+	Invested time.Duration
+}
+
+// Overrun returns whether more time was invested in the tasks of the row
+// than they were estimated to take.
+func (r ReportRow) Overrun() bool {
+	return r.Estimate > 0 && r.Invested > r.Estimate
+}
+
+// An Overrun is a task that took longer than it was estimated to.
+type Overrun struct {
+	Task task.Task
+	// Excess is how much longer than estimated the task took.
+	Excess time.Duration
+}
+
+// A Report aggregates the time log of a TaskTree over a range of time.
+type Report struct {
+	Grouping ReportGrouping
+	// From is the start of the range, zero if it is unbounded.
+	From time.Time
+	// To is the end of the range, exclusive, zero if it is unbounded.
+	To time.Time
+
+	// Rows are in chronological order for GroupByDay and GroupByWeek, and
+	// by logged time otherwise, most first. Groups without logged time are
+	// left out.
+	Rows []ReportRow
+	// Total is the time logged within the range. It is less than the sum of
+	// the rows of a GroupByTag report if time was logged on tasks with
+	// several tags.
+	Total time.Duration
+	// Overruns are the tasks with time logged within the range that took
+	// longer than estimated, most overrun first.
+	Overruns []Overrun
+}
+
+// GetReport aggregates the time logged from one time to another, which may be
+// zero to leave the range unbounded. Sessions are cut to the range, and the
+// running timer counts up to now. Legacy sessions have no real dates, so they
+// only count towards unbounded reports that aren't grouped by date.
+func (tree *TaskTree) GetReport(grouping ReportGrouping, from time.Time, to time.Time) (Report, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if grouping < GroupByDay || grouping > GroupBySubtree {
+		return Report{}, fmt.Errorf("unknown report grouping %v", grouping)
+	}
+
+	report := Report{Grouping: grouping, From: from, To: to}
+	now := time.Now()
+	logged := make(map[string]time.Duration)
+	tasks := make(map[string]map[task.Id]bool)
+	add := func(key string, id task.Id, d time.Duration) {
+		logged[key] += d
+		if tasks[key] == nil {
+			tasks[key] = make(map[task.Id]bool)
+		}
+		tasks[key][id] = true
+	}
+
+	worked := make(map[task.Id]bool)
+	byDate := grouping == GroupByDay || grouping == GroupByWeek
+	for _, s := range tree.sessions {
+		if s.Legacy && (byDate || !from.IsZero() || !to.IsZero()) {
+			continue
+		}
+		start, end := s.Start, s.End
+		if s.Active() {
+			end = now
+		}
+		if !from.IsZero() && start.Before(from) {
+			start = from
+		}
+		if !to.IsZero() && end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+		report.Total += end.Sub(start)
+		worked[s.TaskId] = true
+
+		_, exists := tree.tasks[s.TaskId]
+		switch {
+		case byDate:
+			// Sessions are split at midnight, or at the start of the week.
+			for curr := start; curr.Before(end); {
+				periodStart := task.StartOfDay(curr.In(time.Local))
+				periodEnd := periodStart.AddDate(0, 0, 1)
+				if grouping == GroupByWeek {
+					periodStart = periodStart.AddDate(0, 0, -(int(periodStart.Weekday())+6)%7)
+					periodEnd = periodStart.AddDate(0, 0, 7)
+				}
+				next := periodEnd
+				if end.Before(next) {
+					next = end
+				}
+				add(periodStart.Format(task.DateLayout), s.TaskId, next.Sub(curr))
+				curr = next
+			}
+		case !exists:
+			add(deletedKey, s.TaskId, end.Sub(start))
+		case grouping == GroupByTag:
+			t := tree.tasks[s.TaskId]
+			if len(t.Tags) == 0 {
+				add(untaggedKey, s.TaskId, end.Sub(start))
+			}
+			for _, tag := range t.Tags {
+				add("#"+string(tag), s.TaskId, end.Sub(start))
+			}
+		case grouping == GroupByPriority:
+			add(tree.effectivePriority(s.TaskId).String(), s.TaskId, end.Sub(start))
+		case grouping == GroupBySubtree:
+			rootId := s.TaskId
+			if ancestors := tree.ancestorIds(s.TaskId); len(ancestors) > 0 {
+				rootId = ancestors[len(ancestors)-1]
+			}
+			add(tree.tasks[rootId].Alias+" "+tree.tasks[rootId].Name, s.TaskId, end.Sub(start))
+		}
+	}
+
+	for key, d := range logged {
+		row := ReportRow{Key: key, Logged: d, Tasks: len(tasks[key])}
+		for id := range tasks[key] {
+			if t := tree.tasks[id]; t.EstimatedTime > 0 {
+				row.Estimate += t.EstimatedTime
+				row.Invested += t.TimeInvested
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	slices.SortFunc(report.Rows, func(a, b ReportRow) int {
+		if byDate {
+			return strings.Compare(a.Key, b.Key)
+		}
+		if a.Logged != b.Logged {
+			return cmp.Compare(b.Logged, a.Logged)
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	for id := range worked {
+		t := tree.tasks[id]
+		if t.EstimatedTime > 0 && t.TimeInvested > t.EstimatedTime {
+			report.Overruns = append(report.Overruns, Overrun{Task: t, Excess: t.TimeInvested - t.EstimatedTime})
+		}
+	}
+	slices.SortFunc(report.Overruns, func(a, b Overrun) int {
+		if a.Excess != b.Excess {
+			return cmp.Compare(b.Excess, a.Excess)
+		}
+		return strings.Compare(a.Task.Alias, b.Task.Alias)
+	})
+
+	return report, nil
+}
@@ -0,0 +1,128 @@
+package tasktree
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReportDeletedTasks(t *testing.T) {
+	tree, ids := testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}}}.build(t)
+	now := time.Now()
+	for _, err := range []error{
+		tree.LogTime(ids["a"], now.Add(-3*time.Hour), now.Add(-2*time.Hour)),
+		tree.LogTime(ids["b"], now.Add(-2*time.Hour), now.Add(-90*time.Minute)),
+		tree.LogTime(ids["a1"], now.Add(-time.Hour), now),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.DeleteSubtree(ids["a"]); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, grouping := range []ReportGrouping{GroupByTag, GroupByPriority, GroupBySubtree} {
+		report, err := tree.GetReport(grouping, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Rows) != 2 {
+			t.Fatalf("%v report has rows %+v, want 2", grouping, report.Rows)
+		}
+		if row := report.Rows[0]; row.Key != deletedKey || row.Tasks != 2 || row.Logged != 2*time.Hour {
+			t.Errorf("%v report has first row %+v, want 2h logged on 2 deleted tasks", grouping, row)
+		}
+		if report.Total != 150*time.Minute {
+			t.Errorf("%v report has total %v, want 2h30m", grouping, report.Total)
+		}
+	}
+}
+
+func TestReportByDate(t *testing.T) {
+	tree, ids := testTreeSpec{tasks: [][2]string{{"a", ""}, {"b", ""}}}.build(t)
+	at := func(day int, hour int) time.Time {
+		// 2026-03-01 is a Sunday.
+		return time.Date(2026, 3, day, hour, 0, 0, 0, time.Local)
+	}
+	for _, err := range []error{
+		// Across midnight and the start of a week.
+		tree.LogTime(ids["a"], at(1, 22), at(2, 2)),
+		// Across two midnights.
+		tree.LogTime(ids["b"], at(3, 12), at(5, 12)),
+		tree.LogTime(ids["a"], at(5, 8), at(5, 9)),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Time logged on deleted tasks still counts on the days it was logged.
+	if err := tree.DeleteTask(ids["b"]); err != nil {
+		t.Fatal(err)
+	}
+
+	h := time.Hour
+	tests := []struct {
+		name     string
+		grouping ReportGrouping
+		from     time.Time
+		to       time.Time
+		want     []ReportRow
+	}{
+		{
+			name:     "by day",
+			grouping: GroupByDay,
+			want: []ReportRow{
+				{Key: "2026-03-01", Logged: 2 * h, Tasks: 1},
+				{Key: "2026-03-02", Logged: 2 * h, Tasks: 1},
+				{Key: "2026-03-03", Logged: 12 * h, Tasks: 1},
+				{Key: "2026-03-04", Logged: 24 * h, Tasks: 1},
+				{Key: "2026-03-05", Logged: 13 * h, Tasks: 2},
+			},
+		},
+		{
+			name:     "by week",
+			grouping: GroupByWeek,
+			want: []ReportRow{
+				{Key: "2026-02-23", Logged: 2 * h, Tasks: 1},
+				{Key: "2026-03-02", Logged: 51 * h, Tasks: 2},
+			},
+		},
+		{
+			name:     "by day within a range",
+			grouping: GroupByDay,
+			from:     at(1, 23),
+			to:       at(4, 0),
+			want: []ReportRow{
+				{Key: "2026-03-01", Logged: h, Tasks: 1},
+				{Key: "2026-03-02", Logged: 2 * h, Tasks: 1},
+				{Key: "2026-03-03", Logged: 12 * h, Tasks: 1},
+			},
+		},
+		{
+			name:     "by week within a range",
+			grouping: GroupByWeek,
+			from:     at(2, 0),
+			to:       at(5, 0),
+			want:     []ReportRow{{Key: "2026-03-02", Logged: 38 * h, Tasks: 2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report, err := tree.GetReport(test.grouping, test.from, test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(report.Rows, test.want) {
+				t.Errorf("got rows %+v, want %+v", report.Rows, test.want)
+			}
+			var total time.Duration
+			for _, row := range test.want {
+				total += row.Logged
+			}
+			if report.Total != total {
+				t.Errorf("got total %v, want %v", report.Total, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"time"
+)
+
+// A Rollup aggregates the estimates, invested time and progress of a task
+// and all of its descendants.
+type Rollup struct {
+	// Estimate is the total estimated time of the task and its descendants.
+	Estimate time.Duration
+	// Invested is the total time invested in the task and its descendants.
+	Invested time.Duration
+	// Remaining is the estimated time left on the open tasks among the task
+	// and its descendants. Tasks that took longer than estimated count as zero.
+	Remaining time.Duration
+	// CompletedEstimate is the total estimated time of the completed tasks
+	// among the task and its descendants.
+	CompletedEstimate time.Duration
+
+	// Tasks is the number of descendants of the task, or 1 if it has no subtasks.
+	Tasks int
+	// CompletedTasks is how many of Tasks are completed.
+	CompletedTasks int
+}
+
+// PercentByCount returns the percentage of Tasks that are completed.
+func (r Rollup) PercentByCount() float64 {
+	if r.Tasks == 0 {
+		return 0
+	}
+	return 100 * float64(r.CompletedTasks) / float64(r.Tasks)
+}
+
+// PercentByEstimate returns the percentage of the estimated time belonging to
+// completed tasks, or 0 if nothing is estimated.
+func (r Rollup) PercentByEstimate() float64 {
+	if r.Estimate == 0 {
+		return 0
+	}
+	return 100 * float64(r.CompletedEstimate) / float64(r.Estimate)
+}
+
+// Percent returns PercentByEstimate if anything is estimated, and
+// PercentByCount otherwise.
+func (r Rollup) Percent() float64 {
+	if r.Estimate > 0 {
+		return r.PercentByEstimate()
+	}
+	return r.PercentByCount()
+}
+
+// GetRollup computes the Rollup of a task. Rollups are cached until the
+// TaskTree is next mutated.
+func (tree *TaskTree) GetRollup(id task.Id) (Rollup, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return Rollup{}, err
+	}
+
+	return tree.rollup(id), nil
+}
+
+// rollup implements GetRollup. The caller must hold the lock.
+func (tree *TaskTree) rollup(id task.Id) Rollup {
+	tree.rollupMu.Lock()
+	defer tree.rollupMu.Unlock()
+
+	if tree.rollups == nil || tree.rollupRevision != tree.revision {
+		tree.rollups = make(map[task.Id]Rollup)
+		tree.rollupRevision = tree.revision
+	}
+
+	// Tasks with subtasks aren't counted among their own tasks.
+	r := tree.subtreeRollup(id)
+	if len(tree.subtasks[id]) > 0 {
+		r.Tasks--
+		if tree.tasks[id].Completed {
+			r.CompletedTasks--
+		}
+	}
+	return r
+}
+
+// subtreeRollup computes the rollup of a task, counting the task itself among
+// its tasks, and caches it along with the rollups of its descendants. The
+// caller must hold the lock and tree.rollupMu.
+func (tree *TaskTree) subtreeRollup(id task.Id) Rollup {
+	if r, cached := tree.rollups[id]; cached {
+		return r
+	}
+
+	t := tree.tasks[id]
+	r := Rollup{
+		Estimate: t.EstimatedTime,
+		Invested: t.TimeInvested,
+		Tasks:    1,
+	}
+	if t.Completed {
+		r.CompletedEstimate = t.EstimatedTime
+		r.CompletedTasks = 1
+	} else {
+		r.Remaining = max(t.EstimatedTime-t.TimeInvested, 0)
+	}
+
+	for _, subtaskId := range tree.subtasks[id] {
+		sub := tree.subtreeRollup(subtaskId)
+		r.Estimate += sub.Estimate
+		r.Invested += sub.Invested
+		r.Remaining += sub.Remaining
+		r.CompletedEstimate += sub.CompletedEstimate
+		r.Tasks += sub.Tasks
+		r.CompletedTasks += sub.CompletedTasks
+	}
+
+	tree.rollups[id] = r
+	return r
+}
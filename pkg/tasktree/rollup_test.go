@@ -0,0 +1,114 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"testing"
+	"time"
+)
+
+func TestRollupCache(t *testing.T) {
+	tree, ids := testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"a11", "a1"}, {"a2", "a"}, {"b", ""}}}.build(t)
+	estimate := func(name string, d time.Duration) {
+		tsk, _ := tree.GetTask(ids[name])
+		tsk.EstimatedTime = d
+		if err := tree.UpdateTask(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	estimate("a", time.Hour)
+	estimate("a11", 2*time.Hour)
+	estimate("a2", time.Hour)
+	estimate("b", 3*time.Hour)
+	now := time.Now()
+	if err := tree.LogTime(ids["a11"], now.Add(-30*time.Minute), now); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each step changes a child of a or b, after which the rollups of both
+	// must be up to date even though they were cached before the step.
+	steps := []struct {
+		name string
+		step func() error
+		a, b Rollup
+	}{
+		{
+			name: "initial",
+			step: func() error { return nil },
+			a:    Rollup{Estimate: 4 * time.Hour, Invested: 30 * time.Minute, Remaining: 3*time.Hour + 30*time.Minute, Tasks: 3},
+			b:    Rollup{Estimate: 3 * time.Hour, Remaining: 3 * time.Hour, Tasks: 1},
+		},
+		{
+			name: "edit the estimate of a grandchild",
+			step: func() error {
+				a11, _ := tree.GetTask(ids["a11"])
+				a11.EstimatedTime = 4 * time.Hour
+				return tree.UpdateTask(a11)
+			},
+			a: Rollup{Estimate: 6 * time.Hour, Invested: 30 * time.Minute, Remaining: 5*time.Hour + 30*time.Minute, Tasks: 3},
+			b: Rollup{Estimate: 3 * time.Hour, Remaining: 3 * time.Hour, Tasks: 1},
+		},
+		{
+			name: "log time on a child",
+			step: func() error { return tree.LogTime(ids["a2"], now.Add(-time.Hour), now.Add(-30*time.Minute)) },
+			a:    Rollup{Estimate: 6 * time.Hour, Invested: time.Hour, Remaining: 5 * time.Hour, Tasks: 3},
+			b:    Rollup{Estimate: 3 * time.Hour, Remaining: 3 * time.Hour, Tasks: 1},
+		},
+		{
+			name: "complete a child",
+			step: func() error {
+				_, _, err := tree.CompleteTask(ids["a2"])
+				return err
+			},
+			a: Rollup{Estimate: 6 * time.Hour, Invested: time.Hour, Remaining: 4*time.Hour + 30*time.Minute, CompletedEstimate: time.Hour, Tasks: 3, CompletedTasks: 1},
+			b: Rollup{Estimate: 3 * time.Hour, Remaining: 3 * time.Hour, Tasks: 1},
+		},
+		{
+			name: "move a child to another parent",
+			step: func() error { return tree.MoveTask(ids["a1"], ids["b"], -1) },
+			a:    Rollup{Estimate: 2 * time.Hour, Invested: 30 * time.Minute, Remaining: time.Hour, CompletedEstimate: time.Hour, Tasks: 1, CompletedTasks: 1},
+			b:    Rollup{Estimate: 7 * time.Hour, Invested: 30 * time.Minute, Remaining: 6*time.Hour + 30*time.Minute, Tasks: 2},
+		},
+		{
+			name: "delete a child",
+			step: func() error { return tree.DeleteSubtree(ids["a1"]) },
+			a:    Rollup{Estimate: 2 * time.Hour, Invested: 30 * time.Minute, Remaining: time.Hour, CompletedEstimate: time.Hour, Tasks: 1, CompletedTasks: 1},
+			b:    Rollup{Estimate: 3 * time.Hour, Remaining: 3 * time.Hour, Tasks: 1},
+		},
+		{
+			name: "undo the deletion",
+			step: func() error {
+				_, err := tree.Undo()
+				return err
+			},
+			a: Rollup{Estimate: 2 * time.Hour, Invested: 30 * time.Minute, Remaining: time.Hour, CompletedEstimate: time.Hour, Tasks: 1, CompletedTasks: 1},
+			b: Rollup{Estimate: 7 * time.Hour, Invested: 30 * time.Minute, Remaining: 6*time.Hour + 30*time.Minute, Tasks: 2},
+		},
+		{
+			name: "undo the move",
+			step: func() error {
+				_, err := tree.Undo()
+				return err
+			},
+			a: Rollup{Estimate: 6 * time.Hour, Invested: time.Hour, Remaining: 4*time.Hour + 30*time.Minute, CompletedEstimate: time.Hour, Tasks: 3, CompletedTasks: 1},
+			b: Rollup{Estimate: 3 * time.Hour, Remaining: 3 * time.Hour, Tasks: 1},
+		},
+	}
+	for _, step := range steps {
+		if err := step.step(); err != nil {
+			t.Fatalf("%v: %v", step.name, err)
+		}
+		for name, want := range map[string]Rollup{"a": step.a, "b": step.b} {
+			got, err := tree.GetRollup(ids[name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%v: got rollup of %v %+v, want %+v", step.name, name, got, want)
+			}
+		}
+	}
+
+	if _, err := tree.GetRollup(task.NewId()); err == nil {
+		t.Error("expected an error getting the rollup of a missing task")
+	}
+}
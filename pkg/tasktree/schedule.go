@@ -0,0 +1,138 @@
+package tasktree
+
+import (
+	"container/heap"
+	"github.com/carreter/tasktree-go/pkg/task"
+)
+
+// GetNextActions returns the tasks that can be worked on right now: incomplete
+// tasks without subtasks that have no incomplete blockers, direct or inherited
+// from their ancestors. They are ordered by a topological sort of the blocker
+// graph, see TopologicalOrder.
+func (tree *TaskTree) GetNextActions() []task.Task {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	res := make([]task.Task, 0)
+	for _, id := range tree.topologicalOrder() {
+		if len(tree.subtasks[id]) == 0 && len(tree.openBlockers(id)) == 0 {
+			res = append(res, tree.tasks[id])
+		}
+	}
+	return res
+}
+
+// TopologicalOrder returns the incomplete tasks ordered so that every task
+// comes after the incomplete tasks it waits on, i.e. its blockers and the
+// blockers of its ancestors. Tasks that could come in either order are ordered
+// by priority, then in depth-first order.
+func (tree *TaskTree) TopologicalOrder() []task.Task {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	return tree.idsToTasks(tree.topologicalOrder())
+}
+
+// topologicalOrder implements TopologicalOrder. The caller must hold the lock.
+func (tree *TaskTree) topologicalOrder() []task.Id {
+	ids := make([]task.Id, 0, len(tree.tasks))
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			if !tree.tasks[id].Completed {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return tree.toposort(ids, tree.openBlockers)
+}
+
+// openBlockers returns the incomplete tasks a task waits on. The caller must hold the lock.
+func (tree *TaskTree) openBlockers(id task.Id) []task.Id {
+	res := make([]task.Id, 0)
+	for _, blockerId := range tree.waitsOn(id) {
+		if !tree.tasks[blockerId].Completed {
+			res = append(res, blockerId)
+		}
+	}
+	return res
+}
+
+// toposort orders tasks so that every task comes after the tasks it depends
+// on. Dependencies outside of ids are ignored, and the dependencies must not
+// contain cycles. Tasks that could come in either order are ordered by their
+// effective priority, then by their order in ids. The caller must hold the lock.
+func (tree *TaskTree) toposort(ids []task.Id, dependsOn func(id task.Id) []task.Id) []task.Id {
+	index := make(map[task.Id]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	dependents := make(map[task.Id][]task.Id)
+	remaining := make(map[task.Id]int, len(ids))
+	for _, id := range ids {
+		for _, dependencyId := range dependsOn(id) {
+			if _, inSet := index[dependencyId]; inSet {
+				dependents[dependencyId] = append(dependents[dependencyId], id)
+				remaining[id]++
+			}
+		}
+	}
+
+	ready := &readyQueue{less: func(a, b task.Id) bool {
+		aRank, bRank := priorityRank(tree.effectivePriority(a)), priorityRank(tree.effectivePriority(b))
+		if aRank != bRank {
+			return aRank < bRank
+		}
+		return index[a] < index[b]
+	}}
+	for _, id := range ids {
+		if remaining[id] == 0 {
+			heap.Push(ready, id)
+		}
+	}
+
+	res := make([]task.Id, 0, len(ids))
+	for ready.Len() > 0 {
+		id := heap.Pop(ready).(task.Id)
+		res = append(res, id)
+		for _, dependentId := range dependents[id] {
+			remaining[dependentId]--
+			if remaining[dependentId] == 0 {
+				heap.Push(ready, dependentId)
+			}
+		}
+	}
+	return res
+}
+
+// effectivePriority returns the priority of a task, or that of its closest
+// ancestor with a priority if it has the default priority. The caller must
+// hold the lock.
+func (tree *TaskTree) effectivePriority(id task.Id) task.Priority {
+	if priority := tree.tasks[id].Priority; priority != task.Default {
+		return priority
+	}
+	for _, ancestorId := range tree.ancestorIds(id) {
+		if priority := tree.tasks[ancestorId].Priority; priority != task.Default {
+			return priority
+		}
+	}
+	return task.Default
+}
+
+// A readyQueue is a heap of the tasks ready to be ordered by toposort.
+type readyQueue struct {
+	ids  []task.Id
+	less func(a, b task.Id) bool
+}
+
+func (q *readyQueue) Len() int           { return len(q.ids) }
+func (q *readyQueue) Less(i, j int) bool { return q.less(q.ids[i], q.ids[j]) }
+func (q *readyQueue) Swap(i, j int)      { q.ids[i], q.ids[j] = q.ids[j], q.ids[i] }
+func (q *readyQueue) Push(x any)         { q.ids = append(q.ids, x.(task.Id)) }
+func (q *readyQueue) Pop() any {
+	id := q.ids[len(q.ids)-1]
+	q.ids = q.ids[:len(q.ids)-1]
+	return id
+}
@@ -0,0 +1,167 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"testing"
+)
+
+// A scheduleCase is a tree with priorities and completed tasks, and the names
+// of the tasks expected in order.
+type scheduleCase struct {
+	name       string
+	spec       testTreeSpec
+	priorities map[string]task.Priority
+	completed  []string
+	want       []string
+}
+
+func (c scheduleCase) build(t *testing.T) *TaskTree {
+	t.Helper()
+	tree, ids := c.spec.build(t)
+	for name, priority := range c.priorities {
+		tsk, _ := tree.GetTask(ids[name])
+		tsk.Priority = priority
+		if err := tree.UpdateTask(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range c.completed {
+		if _, _, err := tree.CompleteTask(ids[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tree
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	tests := []scheduleCase{
+		{
+			name: "depth-first",
+			spec: testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"a2", "a"}, {"b", ""}}},
+			want: []string{"a", "a1", "a2", "b"},
+		},
+		{
+			name:       "by priority",
+			spec:       testTreeSpec{tasks: [][2]string{{"a", ""}, {"b", ""}, {"c", ""}, {"d", ""}}},
+			priorities: map[string]task.Priority{"b": task.High, "c": task.Low, "d": task.Urgent},
+			want:       []string{"d", "b", "a", "c"},
+		},
+		{
+			name:       "default ties with normal",
+			spec:       testTreeSpec{tasks: [][2]string{{"a", ""}, {"b", ""}, {"c", ""}}},
+			priorities: map[string]task.Priority{"b": task.Normal},
+			want:       []string{"a", "b", "c"},
+		},
+		{
+			name:       "inherited priority",
+			spec:       testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"a2", "a"}, {"b", ""}, {"c", ""}}},
+			priorities: map[string]task.Priority{"a": task.High, "a2": task.Low, "c": task.Urgent},
+			want:       []string{"c", "a", "a1", "b", "a2"},
+		},
+		{
+			name: "blockers before priority",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"b", ""}},
+				blockers: [][2]string{{"b", "a"}},
+			},
+			priorities: map[string]task.Priority{"a": task.Urgent, "b": task.Low},
+			want:       []string{"b", "a"},
+		},
+		{
+			name: "blockers of ancestors",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}},
+				blockers: [][2]string{{"b", "a"}},
+			},
+			want: []string{"b", "a", "a1"},
+		},
+		{
+			// c becomes ready after a and goes before b and d, which were
+			// ready before it, because of its priority.
+			name: "unblocked by priority",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"b", ""}, {"c", ""}, {"d", ""}},
+				blockers: [][2]string{{"a", "c"}},
+			},
+			priorities: map[string]task.Priority{"c": task.High},
+			want:       []string{"a", "c", "b", "d"},
+		},
+		{
+			name: "completed tasks",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}, {"c", ""}},
+				blockers: [][2]string{{"b", "a"}},
+			},
+			completed: []string{"a1", "b"},
+			want:      []string{"a", "c"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := test.build(t)
+			got := util.Map(tree.TopologicalOrder(), func(t task.Task) string { return t.Name })
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetNextActions(t *testing.T) {
+	tests := []scheduleCase{
+		{
+			name: "leaves",
+			spec: testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"a2", "a"}, {"b", ""}}},
+			want: []string{"a1", "a2", "b"},
+		},
+		{
+			name:       "by priority",
+			spec:       testTreeSpec{tasks: [][2]string{{"a", ""}, {"b", ""}, {"c", ""}}},
+			priorities: map[string]task.Priority{"a": task.Low, "c": task.Urgent},
+			want:       []string{"c", "b", "a"},
+		},
+		{
+			name: "blocked",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"a2", "a"}, {"b", ""}, {"c", ""}},
+				blockers: [][2]string{{"c", "a2"}},
+			},
+			completed: []string{"a1"},
+			want:      []string{"b", "c"},
+		},
+		{
+			name: "blocked through an ancestor",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}},
+				blockers: [][2]string{{"b", "a"}},
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "completed blocker",
+			spec: testTreeSpec{
+				tasks:    [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}},
+				blockers: [][2]string{{"b", "a"}},
+			},
+			completed: []string{"b"},
+			want:      []string{"a1"},
+		},
+		{
+			name:      "completed subtasks",
+			spec:      testTreeSpec{tasks: [][2]string{{"a", ""}, {"a1", "a"}, {"b", ""}}},
+			completed: []string{"a1", "b"},
+			want:      []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := test.build(t)
+			got := util.Map(tree.GetNextActions(), func(t task.Task) string { return t.Name })
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
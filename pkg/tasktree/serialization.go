@@ -3,18 +3,24 @@ package tasktree
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"github.com/carreter/tasktree-go/pkg/task"
+	"io"
+	"sort"
 )
 
 // GobEncode allows for gob encoding of a TaskTree.
 // A custom implementation is necessary here because the TaskTree
 // struct fields are private.
 func (tree *TaskTree) GobEncode() ([]byte, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
 	w := &bytes.Buffer{}
 	encoder := gob.NewEncoder(w)
 
-	// We only need to encode the tree.tasks, tree.subtasks, and tree.blocks
-	// maps as we can reconstruct tree.subtaskOf and tree.blockedBy from these.
+	// We only need to encode the tree.tasks, tree.subtasks, tree.blocks and
+	// tree.roots as we can reconstruct tree.subtaskOf and tree.blockedBy from these.
 	err := encoder.Encode(tree.tasks)
 	if err != nil {
 		return nil, err
@@ -27,6 +33,18 @@ func (tree *TaskTree) GobEncode() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = encoder.Encode(tree.roots)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Encode(tree.completionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Encode(tree.sessions)
+	if err != nil {
+		return nil, err
+	}
 
 	return w.Bytes(), nil
 }
@@ -35,9 +53,14 @@ func (tree *TaskTree) GobEncode() ([]byte, error) {
 // A custom implementation is necessary here because the TaskTree
 // struct fields are private.
 func (tree *TaskTree) GobDecode(buf []byte) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
 	r := bytes.NewBuffer(buf)
 	decoder := gob.NewDecoder(r)
 
+	// Decoding into a non-nil map merges into it, so start from scratch.
+	tree.tasks, tree.subtasks, tree.blocks = nil, nil, nil
 	err := decoder.Decode(&tree.tasks)
 	if err != nil {
 		return err
@@ -50,14 +73,47 @@ func (tree *TaskTree) GobDecode(buf []byte) error {
 	if err != nil {
 		return err
 	}
+	// Older encodings did not include the roots, in which case they are
+	// reconstructed by rehydrate, nor the completion policy or time log.
+	tree.roots = nil
+	err = decoder.Decode(&tree.roots)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	tree.completionPolicy = Independent
+	err = decoder.Decode(&tree.completionPolicy)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	tree.sessions = nil
+	err = decoder.Decode(&tree.sessions)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
 
 	tree.rehydrate()
+	tree.clearHistory()
+	tree.revision++
 
 	return nil
 }
 
-// rehydrate reconstructs the tree.subtaskOf and tree.blockedBy maps.
+// rehydrate reconstructs the tree.subtaskOf and tree.blockedBy maps, as well as
+// tree.roots if it is missing, assigns aliases to tasks that don't have one
+// and makes the time invested in tasks match the time log.
 func (tree *TaskTree) rehydrate() {
+	if tree.tasks == nil {
+		tree.tasks = make(map[task.Id]task.Task)
+	}
+	if tree.subtasks == nil {
+		tree.subtasks = make(map[task.Id][]task.Id)
+	}
+	if tree.blocks == nil {
+		tree.blocks = make(map[task.Id][]task.Id)
+	}
+	tree.subtaskOf = make(map[task.Id]task.Id)
+	tree.blockedBy = make(map[task.Id][]task.Id)
+
 	for parentId, subtaskIds := range tree.subtasks {
 		for _, subtaskId := range subtaskIds {
 			tree.subtaskOf[subtaskId] = parentId
@@ -73,4 +129,17 @@ func (tree *TaskTree) rehydrate() {
 			tree.blockedBy[blockedId] = append(blockedBy, blockerId)
 		}
 	}
+
+	if tree.roots == nil {
+		tree.roots = make([]task.Id, 0)
+		for id := range tree.tasks {
+			if _, isSubtask := tree.subtaskOf[id]; !isSubtask {
+				tree.roots = append(tree.roots, id)
+			}
+		}
+		sort.Slice(tree.roots, func(i, j int) bool { return tree.roots[i] < tree.roots[j] })
+	}
+
+	tree.assignAliases()
+	tree.syncTimeInvested(timerNow())
 }
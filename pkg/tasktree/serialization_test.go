@@ -0,0 +1,139 @@
+package tasktree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"testing"
+	"time"
+)
+
+// legacyGob encodes a tree the way it was saved before roots, completion
+// policies and the time log were, with time invested in a and a1.
+func legacyGob(t *testing.T) []byte {
+	t.Helper()
+	tasks := map[task.Id]task.Task{
+		"a":  {Id: "a", Name: "a", TimeInvested: 2 * time.Hour, Tags: []task.Tag{"work"}},
+		"a1": {Id: "a1", Name: "a1", TimeInvested: 30 * time.Minute},
+		"b":  {Id: "b", Name: "b"},
+	}
+	subtasks := map[task.Id][]task.Id{"a": {"a1"}}
+	blocks := map[task.Id][]task.Id{}
+
+	w := &bytes.Buffer{}
+	encoder := gob.NewEncoder(w)
+	for _, v := range []any{tasks, subtasks, blocks} {
+		if err := encoder.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Bytes()
+}
+
+// legacyJSON is the same tree as legacyGob in the first version of the JSON
+// representation.
+const legacyJSON = `{
+	"version": 1,
+	"tasks": [
+		{"id": "a", "name": "a", "time_invested": "2h0m0s", "completed": false, "tags": ["work"]},
+		{"id": "a1", "name": "a1", "time_invested": "30m0s", "completed": false},
+		{"id": "b", "name": "b", "completed": false}
+	],
+	"roots": ["a", "b"],
+	"subtasks": {"a": ["a1"]},
+	"blocks": {}
+}`
+
+func TestLoadLegacyTimeInvested(t *testing.T) {
+	decoders := map[string]func(tree *TaskTree) error{
+		"gob": func(tree *TaskTree) error {
+			return tree.GobDecode(legacyGob(t))
+		},
+		"json": func(tree *TaskTree) error {
+			return json.Unmarshal([]byte(legacyJSON), tree)
+		},
+	}
+	for name, decode := range decoders {
+		t.Run(name, func(t *testing.T) {
+			tree := NewTaskTree()
+			if err := decode(tree); err != nil {
+				t.Fatal(err)
+			}
+			checkLegacySessions(t, tree)
+
+			// The sessions stay legacy once saved again.
+			for codec, roundTrip := range map[string]func() (*TaskTree, error){
+				"gob": func() (*TaskTree, error) {
+					w := &bytes.Buffer{}
+					if err := gob.NewEncoder(w).Encode(tree); err != nil {
+						return nil, err
+					}
+					decoded := NewTaskTree()
+					return decoded, gob.NewDecoder(w).Decode(decoded)
+				},
+				"json": func() (*TaskTree, error) {
+					data, err := json.Marshal(tree)
+					if err != nil {
+						return nil, err
+					}
+					decoded := NewTaskTree()
+					return decoded, json.Unmarshal(data, decoded)
+				},
+			} {
+				decoded, err := roundTrip()
+				if err != nil {
+					t.Fatalf("%v round trip: %v", codec, err)
+				}
+				checkLegacySessions(t, decoded)
+			}
+		})
+	}
+}
+
+// checkLegacySessions checks that the time invested in the tree of legacyGob
+// and legacyJSON is logged as legacy sessions and left out of the reports
+// that depend on when it was logged.
+func checkLegacySessions(t *testing.T, tree *TaskTree) {
+	t.Helper()
+
+	for id, want := range map[task.Id]time.Duration{"a": 2 * time.Hour, "a1": 30 * time.Minute, "b": 0} {
+		if got, _ := tree.GetTask(id); got.TimeInvested != want {
+			t.Errorf("%v has %v invested, want %v", id, got.TimeInvested, want)
+		}
+	}
+	sessions := tree.GetSessions()
+	if len(sessions) != 2 {
+		t.Fatalf("got sessions %+v, want one for each of a and a1", sessions)
+	}
+	for _, s := range sessions {
+		if !s.Legacy {
+			t.Errorf("session %+v is not marked as legacy", s)
+		}
+	}
+
+	now := time.Now()
+	today := task.StartOfDay(now)
+	reports := []struct {
+		grouping ReportGrouping
+		from     time.Time
+		to       time.Time
+		want     time.Duration
+	}{
+		{GroupByDay, time.Time{}, time.Time{}, 0},
+		{GroupByWeek, time.Time{}, time.Time{}, 0},
+		{GroupByTag, today, today.AddDate(0, 0, 1), 0},
+		{GroupBySubtree, time.Time{}, now.Add(time.Hour), 0},
+		{GroupByTag, time.Time{}, time.Time{}, 150 * time.Minute},
+		{GroupBySubtree, time.Time{}, time.Time{}, 150 * time.Minute},
+	}
+	for _, r := range reports {
+		report, err := tree.GetReport(r.grouping, r.from, r.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Total != r.want {
+			t.Errorf("%v report from %v to %v has total %v, want %v", r.grouping, r.from, r.to, report.Total, r.want)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package tasktree
 import (
 	"fmt"
 	"github.com/carreter/tasktree-go/pkg/task"
-	"github.com/carreter/tasktree-go/pkg/util"
 )
 
 // MarkSubtask marks one task (subtask) as a subtask of another (parent).
+// Use MoveTask to move a task that already has a parent.
+// Returns a *CycleError if this would make the subtask its own ancestor, or if a
+// blocker inherited from the new parent would end up transitively blocking itself.
 func (tree *TaskTree) MarkSubtask(parentId task.Id, subtaskId task.Id) error {
 	tree.rwMu.Lock()
 	defer tree.rwMu.Unlock()
@@ -19,20 +21,23 @@ func (tree *TaskTree) MarkSubtask(parentId task.Id, subtaskId task.Id) error {
 	}
 
 	if existingParentId, exists := tree.subtaskOf[subtaskId]; exists {
-		return fmt.Errorf("task %v is already a subtask of %v", subtaskId, existingParentId)
+		return fmt.Errorf("task %v is already a subtask of %v", tree.label(subtaskId), tree.label(existingParentId))
 	}
 
-	tree.roots = util.Remove(tree.roots, subtaskId)
-
-	// TODO: Add subtask cycle detection.
+	if err := tree.checkSubtaskCycle(parentId, subtaskId); err != nil {
+		return err
+	}
 
-	tree.subtasks[parentId] = append(tree.subtasks[parentId], subtaskId)
-	tree.subtaskOf[subtaskId] = parentId
-	return nil
+	return tree.mutate("mark subtask", func() error {
+		tree.detach(subtaskId)
+		tree.attach(subtaskId, parentId, -1)
+		return nil
+	})
 }
 
-// UnmarkSubtask marks a task as an independent task rather than a subtask.
-// Does not error if task was already an independent task.
+// UnmarkSubtask marks a task as an independent task rather than a subtask,
+// making it the last root task. Does not error if task was already an
+// independent task.
 func (tree *TaskTree) UnmarkSubtask(subtaskId task.Id) error {
 	tree.rwMu.Lock()
 	defer tree.rwMu.Unlock()
@@ -41,14 +46,15 @@ func (tree *TaskTree) UnmarkSubtask(subtaskId task.Id) error {
 		return err
 	}
 
-	parentId, exists := tree.subtaskOf[subtaskId]
-	if !exists {
+	if _, exists := tree.subtaskOf[subtaskId]; !exists {
 		return nil
 	}
 
-	delete(tree.subtaskOf, subtaskId)
-	tree.subtasks[parentId] = util.Remove(tree.subtasks[parentId], subtaskId)
-	return nil
+	return tree.mutate("unmark subtask", func() error {
+		tree.detach(subtaskId)
+		tree.attach(subtaskId, "", -1)
+		return nil
+	})
 }
 
 // GetDirectSubtasksOf gets the direct children of a Task.
@@ -20,6 +20,21 @@ type TaskTree struct {
 
 	blocks    map[task.Id][]task.Id // map from blocking tasks to the tasks they block
 	blockedBy map[task.Id][]task.Id // map from blocked tasks to the tasks they are blocked by
+
+	completionPolicy CompletionPolicy // applied by CompleteTask and ReopenTask
+
+	sessions []Session // time log, including the active timer
+
+	revision uint64 // incremented on every mutation
+
+	pending      *operation   // operation currently being recorded by mutate
+	undoStack    []*operation // operations that can be undone, most recent last
+	redoStack    []*operation // operations that can be redone, most recently undone last
+	historyDepth int          // maximum length of undoStack and redoStack
+
+	rollupMu       sync.Mutex         // guards the rollup cache, which is filled under the read lock
+	rollups        map[task.Id]Rollup // cached rollups, see GetRollup
+	rollupRevision uint64             // revision the cached rollups were computed at
 }
 
 // NewTaskTree creates a new, empty TaskTree.
@@ -31,6 +46,8 @@ func NewTaskTree() *TaskTree {
 		subtaskOf: make(map[task.Id]task.Id),
 		blocks:    make(map[task.Id][]task.Id),
 		blockedBy: make(map[task.Id][]task.Id),
+
+		historyDepth: DefaultHistoryDepth,
 	}
 }
 
@@ -48,17 +65,55 @@ func (tree *TaskTree) idsToTasks(ids []task.Id) []task.Task {
 	})
 }
 
-// AddTask adds a Task object to the TaskTree.
+// AddTask adds a Task object to the TaskTree. Time already invested in the
+// task is logged as a session ending now.
 func (tree *TaskTree) AddTask(task task.Task) error {
 	tree.rwMu.Lock()
 	defer tree.rwMu.Unlock()
 
+	return tree.mutate("add task", func() error {
+		return tree.addTask(task, "")
+	})
+}
+
+// AddSubtask adds a Task object to the TaskTree as a subtask of another task.
+func (tree *TaskTree) AddSubtask(parentId task.Id, task task.Task) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(parentId); err != nil {
+		return err
+	}
+
+	return tree.mutate("add subtask", func() error {
+		return tree.addTask(task, parentId)
+	})
+}
+
+// addTask adds a task as the last subtask of a parent task, or as the last
+// root task if parentId is empty. Assigns an alias to the task if it doesn't
+// have one. The caller must hold the write lock.
+func (tree *TaskTree) addTask(task task.Task, parentId task.Id) error {
+	if task.Id == "" {
+		return fmt.Errorf("task id must not be empty")
+	}
 	if _, exists := tree.tasks[task.Id]; exists {
 		return fmt.Errorf("task with id %v already exists", task.Id)
 	}
+	if task.Alias == "" {
+		task.Alias = tree.nextAlias()
+	} else if err := tree.checkAlias(task); err != nil {
+		return err
+	}
 
-	tree.tasks[task.Id] = task
-	tree.roots = append(tree.roots, task.Id)
+	invested := task.TimeInvested
+	task.TimeInvested = 0
+	tree.setTask(task)
+	tree.attach(task.Id, parentId, -1)
+	if invested > 0 {
+		now := timerNow()
+		tree.logSession(Session{TaskId: task.Id, Start: now.Add(-invested), End: now, Legacy: true})
+	}
 	return nil
 }
 
@@ -70,43 +125,41 @@ func (tree *TaskTree) GetTask(id task.Id) (task task.Task, exists bool) {
 	return
 }
 
-// DeleteTask deletes a task from the tree by id.
-func (tree *TaskTree) DeleteTask(id task.Id) error {
+// UpdateTask replaces a task in the tree with an updated version. The alias
+// of the task is kept if the updated version doesn't have one.
+func (tree *TaskTree) UpdateTask(task task.Task) error {
 	tree.rwMu.Lock()
 	defer tree.rwMu.Unlock()
 
-	if err := tree.assertTaskExists(id); err != nil {
-		return err
+	old, exists := tree.tasks[task.Id]
+	if !exists {
+		return fmt.Errorf("task with id %v does not exist", task.Id)
 	}
-
-	parentId, isSubtask := tree.subtaskOf[id]
-	if !isSubtask {
-		tree.roots = util.Remove(tree.roots, id)
-	} else {
-		tree.subtasks[parentId] = util.Remove(tree.subtasks[parentId], id)
-		delete(tree.subtaskOf, id)
+	if task.Alias == "" {
+		task.Alias = old.Alias
+	} else if err := tree.checkAlias(task); err != nil {
+		return err
 	}
+	// The time invested is derived from the time log, see LogTime.
+	task.TimeInvested = old.TimeInvested
 
-	delete(tree.tasks, id)
-	for _, subtaskId := range tree.subtasks[id] {
-		delete(tree.subtaskOf, subtaskId)
-		tree.roots = append(tree.roots, subtaskId)
-	}
-	delete(tree.subtasks, id)
-	return nil
+	return tree.mutate("update task", func() error {
+		tree.setTask(task)
+		return nil
+	})
 }
 
-// UpdateTask replaces a task in the tree with an updated version.
-func (tree *TaskTree) UpdateTask(task task.Task) error {
-	tree.rwMu.Lock()
-	defer tree.rwMu.Unlock()
+// GetAllTasks returns every task in the tree in depth-first order, i.e. each
+// root task followed by its subtasks.
+func (tree *TaskTree) GetAllTasks() []task.Task {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
 
-	if _, exists := tree.tasks[task.Id]; !exists {
-		return fmt.Errorf("task with id %v does not exist", task.Id)
+	res := make([]task.Task, 0, len(tree.tasks))
+	for _, rootId := range tree.roots {
+		res = append(res, tree.idsToTasks(tree.subtreeIds(rootId))...)
 	}
-
-	tree.tasks[task.Id] = task
-	return nil
+	return res
 }
 
 // GetRootTasks returns the root tasks (i.e. tasks that aren't subtasks/don't have parents).
@@ -115,3 +168,11 @@ func (tree *TaskTree) GetRootTasks() []task.Task {
 	defer tree.rwMu.RUnlock()
 	return util.Map(tree.roots, func(id task.Id) task.Task { return tree.tasks[id] })
 }
+
+// Revision returns a counter that is incremented every time the TaskTree is mutated.
+// Comparing revisions is a cheap way to tell whether a TaskTree has changed.
+func (tree *TaskTree) Revision() uint64 {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+	return tree.revision
+}
@@ -0,0 +1,72 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"strings"
+	"testing"
+)
+
+// newTestTask creates a task with a fresh id.
+func newTestTask(name string) task.Task {
+	return task.Task{Id: task.NewId(), Name: name}
+}
+
+// mustAdd adds a task to a tree, under a parent unless parentId is empty.
+func mustAdd(t *testing.T, tree *TaskTree, parentId task.Id, tsk task.Task) task.Task {
+	t.Helper()
+	var err error
+	if parentId == "" {
+		err = tree.AddTask(tsk)
+	} else {
+		err = tree.AddSubtask(parentId, tsk)
+	}
+	if err != nil {
+		t.Fatalf("failed to add task %q: %v", tsk.Name, err)
+	}
+	added, _ := tree.GetTask(tsk.Id)
+	return added
+}
+
+// outline describes the tasks of a tree depth-first, one line per task with
+// the fields a codec keeps, so that trees can be compared regardless of ids.
+func outline(tree *TaskTree, fields func(task.Task) string) string {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	var lines []string
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			depth := len(tree.ancestorIds(id))
+			lines = append(lines, strings.Repeat("  ", depth)+fields(tree.tasks[id]))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// testTreeSpec describes a tree by task name: the parent of each task, in the
+// order they are added, and blocker pairs.
+type testTreeSpec struct {
+	tasks    [][2]string // name, parent name (empty for roots)
+	blockers [][2]string // blocker name, blocked name
+}
+
+func (spec testTreeSpec) build(t *testing.T) (*TaskTree, map[string]task.Id) {
+	t.Helper()
+	tree := NewTaskTree()
+	ids := make(map[string]task.Id)
+	for _, tsk := range spec.tasks {
+		ids[tsk[0]] = mustAdd(t, tree, ids[tsk[1]], newTestTask(tsk[0])).Id
+	}
+	for _, b := range spec.blockers {
+		if err := tree.MarkBlocker(ids[b[0]], ids[b[1]]); err != nil {
+			t.Fatalf("failed to mark %v as blocking %v: %v", b[0], b[1], err)
+		}
+	}
+	return tree, ids
+}
+
+// names returns the names of tasks in a list of ids.
+func names(tree *TaskTree, ids []task.Id) []string {
+	return util.Map(ids, func(id task.Id) string { return tree.tasks[id].Name })
+}
@@ -0,0 +1,230 @@
+package tasktree
+
+import (
+	"errors"
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"slices"
+	"time"
+)
+
+// ErrNoActiveTimer is returned by StopTimer when no timer is running.
+var ErrNoActiveTimer = errors.New("no timer is running")
+
+// A Session is a period of time spent working on a task. The sessions of a
+// TaskTree form its time log, from which the TimeInvested of every task is
+// derived.
+type Session struct {
+	TaskId task.Id
+	Start  time.Time
+	// End is zero while the session is the active timer.
+	End time.Time
+	// Pomodoro marks a completed pomodoro work interval.
+	Pomodoro bool
+	// Legacy marks time invested in a task without being logged, as in
+	// files saved before sessions were. Its dates are unknown: the session
+	// ends when it was added to the time log and only its length is real.
+	Legacy bool
+}
+
+// Active returns whether the session is the active timer.
+func (s Session) Active() bool {
+	return s.End.IsZero()
+}
+
+// Duration returns the length of the session, up to now if it is active.
+func (s Session) Duration(now time.Time) time.Duration {
+	if s.Active() {
+		return max(now.Sub(s.Start), 0)
+	}
+	return s.End.Sub(s.Start)
+}
+
+// timerNow returns the current time as recorded in the time log, without
+// fractions of a second.
+func timerNow() time.Time {
+	return time.Now().Round(0).Truncate(time.Second)
+}
+
+// StartTimer starts timing work on a task. At most one timer runs at a time,
+// so a timer running for another task is stopped first and its session is
+// returned.
+func (tree *TaskTree) StartTimer(id task.Id) (stopped Session, wasRunning bool, err error) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return Session{}, false, err
+	}
+	active, isActive := tree.activeTimer()
+	if isActive && active.TaskId == id {
+		return Session{}, false, fmt.Errorf("a timer is already running for task %v", tree.label(id))
+	}
+
+	err = tree.mutate("start timer", func() error {
+		now := timerNow()
+		if isActive {
+			stopped = tree.stopTimer(now)
+		}
+		tree.setSessions(append(slices.Clone(tree.sessions), Session{TaskId: id, Start: now}))
+		return nil
+	})
+	return stopped, isActive, err
+}
+
+// StopTimer stops the active timer, adding its session to the time log.
+// Returns ErrNoActiveTimer if no timer is running.
+func (tree *TaskTree) StopTimer() (Session, error) {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	if _, isActive := tree.activeTimer(); !isActive {
+		return Session{}, ErrNoActiveTimer
+	}
+
+	var stopped Session
+	err := tree.mutate("stop timer", func() error {
+		stopped = tree.stopTimer(timerNow())
+		return nil
+	})
+	return stopped, err
+}
+
+// ActiveTimer returns the session of the running timer, if any.
+func (tree *TaskTree) ActiveTimer() (Session, bool) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	return tree.activeTimer()
+}
+
+// LogTime adds a session that has already ended to the time log.
+func (tree *TaskTree) LogTime(id task.Id, start time.Time, end time.Time) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	return tree.logTime("log time", Session{TaskId: id, Start: start, End: end})
+}
+
+// LogPomodoro adds a completed pomodoro work interval to the time log.
+func (tree *TaskTree) LogPomodoro(id task.Id, start time.Time, end time.Time) error {
+	tree.rwMu.Lock()
+	defer tree.rwMu.Unlock()
+
+	return tree.logTime("log pomodoro", Session{TaskId: id, Start: start, End: end, Pomodoro: true})
+}
+
+// GetPomodoroCount returns the number of pomodoros completed on a task.
+func (tree *TaskTree) GetPomodoroCount(id task.Id) (int, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	if err := tree.assertTaskExists(id); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, s := range tree.sessions {
+		if s.TaskId == id && s.Pomodoro {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetSessions returns the time log, including the active timer, in the order
+// the sessions were logged.
+func (tree *TaskTree) GetSessions() []Session {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	return slices.Clone(tree.sessions)
+}
+
+// logTime implements LogTime and LogPomodoro. The caller must hold the write
+// lock.
+func (tree *TaskTree) logTime(name string, s Session) error {
+	if err := tree.assertTaskExists(s.TaskId); err != nil {
+		return err
+	}
+	if !s.End.After(s.Start) {
+		return fmt.Errorf("session must end after it starts")
+	}
+
+	return tree.mutate(name, func() error {
+		tree.logSession(s)
+		return nil
+	})
+}
+
+// activeTimer implements ActiveTimer. The caller must hold the lock.
+func (tree *TaskTree) activeTimer() (Session, bool) {
+	for _, s := range tree.sessions {
+		if s.Active() {
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// stopTimer ends the active session, which must exist, and adds it to the
+// time invested in its task. The caller must hold the write lock and be
+// inside of mutate.
+func (tree *TaskTree) stopTimer(now time.Time) Session {
+	sessions := slices.DeleteFunc(slices.Clone(tree.sessions), Session.Active)
+	active, _ := tree.activeTimer()
+	active.End = now
+	tree.setSessions(sessions)
+	tree.logSession(active)
+	return active
+}
+
+// logSession appends an ended session to the time log and adds it to the time
+// invested in its task. The caller must hold the write lock and be inside of
+// mutate.
+func (tree *TaskTree) logSession(s Session) {
+	tree.setSessions(append(slices.Clone(tree.sessions), s))
+	t := tree.tasks[s.TaskId]
+	t.TimeInvested += s.Duration(s.End)
+	tree.setTask(t)
+}
+
+// stopDeletedTimer stops the active timer if its task no longer exists,
+// keeping its session in the time log like those of other deleted tasks. The
+// caller must hold the write lock and be inside of mutate.
+func (tree *TaskTree) stopDeletedTimer(now time.Time) {
+	active, isActive := tree.activeTimer()
+	if !isActive {
+		return
+	}
+	if _, exists := tree.tasks[active.TaskId]; exists {
+		return
+	}
+	active.End = now
+	tree.setSessions(append(slices.DeleteFunc(slices.Clone(tree.sessions), Session.Active), active))
+}
+
+// syncTimeInvested makes the time invested in every task match the time log
+// after loading a TaskTree. Time invested in a task beyond what its sessions
+// add up to, as in trees saved before sessions were logged, is logged as a
+// legacy session ending now. The caller must hold the write lock.
+func (tree *TaskTree) syncTimeInvested(now time.Time) {
+	logged := make(map[task.Id]time.Duration)
+	for _, s := range tree.sessions {
+		if !s.Active() {
+			logged[s.TaskId] += s.Duration(s.End)
+		}
+	}
+
+	// Iterate in a stable order so that the sessions are too.
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			t := tree.tasks[id]
+			if t.TimeInvested > logged[id] {
+				tree.sessions = append(tree.sessions, Session{TaskId: id, Start: now.Add(logged[id] - t.TimeInvested), End: now, Legacy: true})
+			} else {
+				t.TimeInvested = logged[id]
+				tree.tasks[id] = t
+			}
+		}
+	}
+}
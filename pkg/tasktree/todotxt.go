@@ -0,0 +1,248 @@
+package tasktree
+
+import (
+	"fmt"
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todoTxtPriorities maps priorities to todo.txt priority letters. Letters
+// after the last one are read as the lowest priority.
+var todoTxtPriorities = map[task.Priority]byte{
+	task.Urgent: 'A',
+	task.High:   'B',
+	task.Normal: 'C',
+	task.Low:    'D',
+}
+
+// todoTxtPriority matches the priority of an open todo.txt task, e.g. "(A)".
+var todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+
+// parseTodoTxtPriority parses a todo.txt priority letter.
+func parseTodoTxtPriority(letter byte) task.Priority {
+	for p, l := range todoTxtPriorities {
+		if l == letter {
+			return p
+		}
+	}
+	return task.Low
+}
+
+// todoTxtProject returns the +project token naming a task, which can't
+// contain spaces.
+func todoTxtProject(name string) string {
+	return "+" + strings.ReplaceAll(name, " ", "_")
+}
+
+// todoTxtExtensions are the key:value extensions read into task fields.
+var todoTxtExtensions = []string{"due", "t", "pri"}
+
+// escapeTodoTxtName escapes the words of a task name that would be read back
+// as something else with a backslash: +projects, @contexts, extensions, and
+// a leading "x", priority or date.
+func escapeTodoTxtName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		key, _, isExtension := strings.Cut(word, ":")
+		_, isDate := parseTodoTxtDate(word)
+		escape := strings.HasPrefix(word, `\`) ||
+			len(word) > 1 && (word[0] == '+' || word[0] == '@') ||
+			isExtension && util.Contains(todoTxtExtensions, key) ||
+			i == 0 && (word == "x" || todoTxtPriority.MatchString(word) || isDate)
+		if escape {
+			words[i] = `\` + word
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// parseTodoTxtDate parses a todo.txt date, e.g. "2026-10-18".
+func parseTodoTxtDate(s string) (time.Time, bool) {
+	date, err := time.ParseInLocation(task.DateLayout, s, time.Local)
+	return date, err == nil
+}
+
+// MarshalTodoTxt encodes a TaskTree in the todo.txt format, one line per
+// task such as "(B) fix bug +release +ship_v2 @work due:2026-10-23". Words
+// of names that would be read back as something else are escaped with a
+// backslash, see escapeTodoTxtName. The
+// ancestors of a task are written as +project tokens from the root down, with
+// spaces in their names replaced by underscores, and its tags as @contexts.
+// The due and start dates are written as the due: and t: extensions. Tasks
+// are written depth-first so that parents come before their subtasks.
+func (tree *TaskTree) MarshalTodoTxt() ([]byte, error) {
+	tree.rwMu.RLock()
+	defer tree.rwMu.RUnlock()
+
+	var sb strings.Builder
+	for _, rootId := range tree.roots {
+		for _, id := range tree.subtreeIds(rootId) {
+			t := tree.tasks[id]
+
+			var words []string
+			if t.Completed {
+				words = append(words, "x")
+				if !t.CompletedAt.IsZero() {
+					words = append(words, t.CompletedAt.In(time.Local).Format(task.DateLayout))
+				}
+			} else if letter, exists := todoTxtPriorities[t.Priority]; exists {
+				words = append(words, fmt.Sprintf("(%c)", letter))
+			}
+			words = append(words, escapeTodoTxtName(t.Name))
+
+			ancestorIds := tree.ancestorIds(id)
+			for i := len(ancestorIds) - 1; i >= 0; i-- {
+				words = append(words, todoTxtProject(tree.tasks[ancestorIds[i]].Name))
+			}
+			for _, tag := range t.Tags {
+				words = append(words, "@"+string(tag))
+			}
+			if !t.Due.IsZero() {
+				words = append(words, "due:"+t.Due.In(time.Local).Format(task.DateLayout))
+			}
+			if !t.Start.IsZero() {
+				words = append(words, "t:"+t.Start.In(time.Local).Format(task.DateLayout))
+			}
+			// Completed tasks keep their priority as an extension, as the
+			// priority letter can't follow the "x".
+			if letter, exists := todoTxtPriorities[t.Priority]; exists && t.Completed {
+				words = append(words, fmt.Sprintf("pri:%c", letter))
+			}
+
+			sb.WriteString(strings.Join(words, " ") + "\n")
+		}
+	}
+	return []byte(sb.String()), nil
+}
+
+// UnmarshalTodoTxt decodes a TaskTree from the todo.txt format, replacing the
+// contents of the TaskTree. Each line becomes a task. Its +project tokens
+// name the tasks it is a subtask of, from the root down, matching the names
+// of tasks with spaces replaced by underscores; projects without a line of
+// their own are created. @contexts become tags, the due: and t: extensions
+// set the due and start dates, and pri: sets the priority of completed tasks.
+// Creation dates are ignored, and other key:value extensions are kept in the
+// name, as are words escaped with a backslash, without it.
+func (tree *TaskTree) UnmarshalTodoTxt(data []byte) error {
+	decoded := NewTaskTree()
+	// Projects created before the line of the task they name.
+	placeholders := make(map[task.Id]bool)
+
+	// findProject returns the subtask of a parent named by a +project token.
+	findProject := func(parentId task.Id, project string) (task.Id, bool) {
+		for _, id := range decoded.siblings(parentId) {
+			if todoTxtProject(decoded.tasks[id].Name) == project {
+				return id, true
+			}
+		}
+		return "", false
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		t, projects, err := parseTodoTxtLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		var parentId task.Id
+		for _, project := range projects {
+			projectId, exists := findProject(parentId, project)
+			if !exists {
+				placeholder := task.Task{Id: task.NewId(), Name: strings.ReplaceAll(project[1:], "_", " ")}
+				if err := decoded.addTask(placeholder, parentId); err != nil {
+					return fmt.Errorf("line %d: %w", i+1, err)
+				}
+				projectId = placeholder.Id
+				placeholders[projectId] = true
+			}
+			parentId = projectId
+		}
+
+		// The line of a project created earlier fills it in.
+		if id, exists := findProject(parentId, todoTxtProject(t.Name)); exists && placeholders[id] {
+			t.Id = id
+			t.Alias = decoded.tasks[id].Alias
+			decoded.tasks[id] = t
+			delete(placeholders, id)
+			continue
+		}
+		if err := decoded.addTask(t, parentId); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	tree.replace(decoded)
+	return nil
+}
+
+// parseTodoTxtLine parses a line of a todo.txt file into a new task and the
+// +project tokens of its ancestors.
+func parseTodoTxtLine(line string) (task.Task, []string, error) {
+	t := task.Task{Id: task.NewId()}
+	words := strings.Fields(line)
+
+	if words[0] == "x" {
+		t.Completed = true
+		words = words[1:]
+		if len(words) > 0 {
+			if date, isDate := parseTodoTxtDate(words[0]); isDate {
+				t.CompletedAt = date
+				words = words[1:]
+			}
+		}
+	} else if match := todoTxtPriority.FindStringSubmatch(words[0]); match != nil {
+		t.Priority = parseTodoTxtPriority(match[1][0])
+		words = words[1:]
+	}
+	// Skip the creation date.
+	if len(words) > 0 {
+		if _, isDate := parseTodoTxtDate(words[0]); isDate {
+			words = words[1:]
+		}
+	}
+
+	var name, projects []string
+	for _, word := range words {
+		if unescaped, isEscaped := strings.CutPrefix(word, `\`); isEscaped {
+			name = append(name, unescaped)
+			continue
+		}
+		if len(word) > 1 && word[0] == '+' {
+			projects = append(projects, word)
+			continue
+		}
+		if len(word) > 1 && word[0] == '@' {
+			if tag := task.Tag(word[1:]); !util.Contains(t.Tags, tag) {
+				t.Tags = append(t.Tags, tag)
+			}
+			continue
+		}
+
+		key, value, isExtension := strings.Cut(word, ":")
+		date, isDate := parseTodoTxtDate(value)
+		switch {
+		case isExtension && key == "due" && isDate:
+			t.Due = date
+		case isExtension && key == "t" && isDate:
+			t.Start = date
+		case isExtension && key == "pri" && todoTxtPriority.MatchString("("+value+")"):
+			t.Priority = parseTodoTxtPriority(value[0])
+		default:
+			name = append(name, word)
+		}
+	}
+
+	t.Name = strings.Join(name, " ")
+	if t.Name == "" {
+		return task.Task{}, nil, fmt.Errorf("task %q has no name", line)
+	}
+	return t, projects, nil
+}
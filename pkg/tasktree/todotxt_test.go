@@ -0,0 +1,203 @@
+package tasktree
+
+import (
+	"github.com/carreter/tasktree-go/pkg/task"
+	"github.com/carreter/tasktree-go/pkg/util"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func todoTxtFields(t task.Task) string {
+	fields := []string{
+		"name=" + t.Name,
+		"completed=" + map[bool]string{true: "yes", false: "no"}[t.Completed],
+		"priority=" + t.Priority.String(),
+		"tags=" + strings.Join(util.Map(t.Tags, func(tag task.Tag) string { return string(tag) }), ","),
+	}
+	for name, date := range map[string]time.Time{"due": t.Due, "start": t.Start, "completed_at": t.CompletedAt} {
+		if !date.IsZero() {
+			fields = append(fields, name+"="+date.Format(task.DateLayout))
+		}
+	}
+	slices.Sort(fields[4:])
+	return strings.Join(fields, " ")
+}
+
+// roundTripTodoTxt exports a tree, imports the result and exports it again,
+// failing if the two exports or the two trees differ.
+func roundTripTodoTxt(t *testing.T, tree *TaskTree) (string, *TaskTree) {
+	t.Helper()
+	first, err := tree.MarshalTodoTxt()
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	imported := NewTaskTree()
+	if err := imported.UnmarshalTodoTxt(first); err != nil {
+		t.Fatalf("failed to import %q: %v", first, err)
+	}
+	second, err := imported.MarshalTodoTxt()
+	if err != nil {
+		t.Fatalf("failed to export the imported tree: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("export changed after import:\nfirst:\n%v\nsecond:\n%v", first, second)
+	}
+	if want, got := outline(tree, todoTxtFields), outline(imported, todoTxtFields); want != got {
+		t.Errorf("imported tree differs:\nwant:\n%v\ngot:\n%v", want, got)
+	}
+	return string(first), imported
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.October, d, 0, 0, 0, 0, time.Local) }
+
+	tests := []struct {
+		name  string
+		build func(t *testing.T, tree *TaskTree)
+		want  string
+	}{
+		{
+			name: "plain tasks",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", newTestTask("buy milk"))
+				mustAdd(t, tree, "", newTestTask("call mom"))
+			},
+			want: "buy milk\ncall mom\n",
+		},
+		{
+			name: "priorities",
+			build: func(t *testing.T, tree *TaskTree) {
+				for _, p := range []task.Priority{task.Urgent, task.High, task.Normal, task.Low} {
+					tsk := newTestTask(p.String())
+					tsk.Priority = p
+					mustAdd(t, tree, "", tsk)
+				}
+			},
+			want: "(A) urgent\n(B) high\n(C) normal\n(D) low\n",
+		},
+		{
+			name: "completed with priority and date",
+			build: func(t *testing.T, tree *TaskTree) {
+				tsk := newTestTask("pay rent")
+				tsk.Completed = true
+				tsk.CompletedAt = day(15)
+				tsk.Priority = task.High
+				mustAdd(t, tree, "", tsk)
+			},
+			want: "x 2026-10-15 pay rent pri:B\n",
+		},
+		{
+			name: "tags and dates",
+			build: func(t *testing.T, tree *TaskTree) {
+				tsk := newTestTask("fix sink")
+				tsk.Tags = []task.Tag{"home", "errands"}
+				tsk.Due = day(20)
+				tsk.Start = day(18)
+				mustAdd(t, tree, "", tsk)
+			},
+			want: "fix sink @home @errands due:2026-10-20 t:2026-10-18\n",
+		},
+		{
+			name: "nested projects",
+			build: func(t *testing.T, tree *TaskTree) {
+				release := mustAdd(t, tree, "", newTestTask("release"))
+				ship := mustAdd(t, tree, release.Id, newTestTask("ship v2"))
+				mustAdd(t, tree, ship.Id, newTestTask("write changelog"))
+				mustAdd(t, tree, release.Id, newTestTask("announce"))
+			},
+			want: "release\nship v2 +release\nwrite changelog +release +ship_v2\nannounce +release\n",
+		},
+		{
+			name: "names that need escaping",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", newTestTask("x marks the spot"))
+				mustAdd(t, tree, "", newTestTask("(A) is not a priority"))
+				mustAdd(t, tree, "", newTestTask("2026-01-01 party"))
+				parent := mustAdd(t, tree, "", newTestTask("email @bob +proj due:tomorrow"))
+				mustAdd(t, tree, parent.Id, newTestTask(`back\slash \x pri:A t:2026-10-01`))
+			},
+			want: `\x marks the spot` + "\n" +
+				`\(A) is not a priority` + "\n" +
+				`\2026-01-01 party` + "\n" +
+				`email \@bob \+proj \due:tomorrow` + "\n" +
+				`back\slash \\x \pri:A \t:2026-10-01 +email_@bob_+proj_due:tomorrow` + "\n",
+		},
+		{
+			name: "unknown extensions stay in the name",
+			build: func(t *testing.T, tree *TaskTree) {
+				mustAdd(t, tree, "", newTestTask("read url:https://example.com"))
+			},
+			want: "read url:https://example.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewTaskTree()
+			tt.build(t, tree)
+			if got, _ := roundTripTodoTxt(t, tree); got != tt.want {
+				t.Errorf("got export:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTodoTxt(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "creation dates are skipped",
+			input: "(A) 2026-10-01 call mom\nx 2026-10-15 2026-10-01 pay rent\n",
+			want: "name=call mom completed=no priority=urgent tags=\n" +
+				"name=pay rent completed=yes priority=default tags= completed_at=2026-10-15",
+		},
+		{
+			name:  "late priority letters are low",
+			input: "(Z) someday\n",
+			want:  "name=someday completed=no priority=low tags=",
+		},
+		{
+			name:  "projects without a line are created",
+			input: "fix sink +home +kitchen\n",
+			want: "name=home completed=no priority=default tags=\n" +
+				"  name=kitchen completed=no priority=default tags=\n" +
+				"    name=fix sink completed=no priority=default tags=",
+		},
+		{
+			name:  "project lines after their tasks fill the project in",
+			input: "fix sink +home\n(B) home @house\n",
+			want: "name=home completed=no priority=high tags=house\n" +
+				"  name=fix sink completed=no priority=default tags=",
+		},
+		{
+			name:  "invalid extension values stay in the name",
+			input: "plan due:someday pri:high\n",
+			want:  "name=plan due:someday pri:high completed=no priority=default tags=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := NewTaskTree()
+			if err := tree.UnmarshalTodoTxt([]byte(tt.input)); err != nil {
+				t.Fatalf("failed to import: %v", err)
+			}
+			if got := outline(tree, todoTxtFields); got != tt.want {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTodoTxtErrors(t *testing.T) {
+	for _, input := range []string{"+project @context\n", "x\n", "(A)\n"} {
+		if err := NewTaskTree().UnmarshalTodoTxt([]byte(input)); err == nil {
+			t.Errorf("importing %q succeeded, expected an error", input)
+		}
+	}
+}